@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/kevin7254/task/dateparse"
 	"github.com/kevin7254/task/model"
 	"github.com/kevin7254/task/store"
 	"github.com/spf13/cobra"
@@ -15,6 +16,11 @@ func NewAddCmd(store store.TaskRepository) *cobra.Command {
 		project     string
 		priority    int
 		dueDate     string
+		tags        []string
+		parent      string
+		depends     []string
+		recur       string
+		until       string
 	)
 
 	addCmd := &cobra.Command{
@@ -24,29 +30,75 @@ func NewAddCmd(store store.TaskRepository) *cobra.Command {
 
 Example:
   task add "Complete project report"
-  task add "Force push to prod" --project work --priority 2 --due 2025-06-03`,
+  task add "Force push to prod" --project work --priority 2 --due 2025-06-03
+  task add "Call the bank" --due "tomorrow 14:00"
+  task add "Plan sprint" --due "next monday"
+  task add "Review PR" +review +urgent
+  task add "Write tests" --parent 12
+  task add "Deploy" --depends 4,5
+  task add "Weekly report" --due 2025-06-06 --recur weekly:fri --until 2025-12-31`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			taskName := strings.Join(args, " ")
+			words, argTags := splitTagArgs(args)
+			taskName := strings.Join(words, " ")
 			if taskName == "" {
 				return fmt.Errorf("task name cannot be empty")
 			}
 
 			var due time.Time
 			if dueDate != "" {
-				parsedDate, err := time.Parse("2006-01-02", dueDate)
+				parsedDate, err := parseDateFlag(dueDate)
 				if err != nil {
-					return fmt.Errorf("invalid date format: %w", err)
+					return err
 				}
 				due = parsedDate
-			} else {
-				due = time.Now().AddDate(0, 0, 1)
 			}
 
 			if priority < 1 || priority > 3 {
 				return fmt.Errorf("priority must be between 1 (Low) and 3 (High)")
 			}
 
+			var parentID int
+			if parent != "" {
+				id, err := parseTaskRefFlag(store, parent)
+				if err != nil {
+					return err
+				}
+				parentID = id
+			}
+			if parentID != 0 && store.GetTaskByID(parentID) == nil {
+				return fmt.Errorf("parent task with ID %d not found", parentID)
+			}
+
 			newTask := model.NewTask(taskName, description, project, model.Priority(priority), due)
+			newTask.AddTags(argTags...)
+			newTask.AddTags(tags...)
+			newTask.ParentID = parentID
+
+			dependsOn, err := parseIDList(store, depends)
+			if err != nil {
+				return err
+			}
+			newTask.DependsOn = dependsOn
+			if err := model.ValidateDependencies(newTask, store.GetTaskByID); err != nil {
+				return err
+			}
+
+			if recur != "" {
+				rule, err := model.ParseRecurrence(recur)
+				if err != nil {
+					return err
+				}
+				if until != "" {
+					untilDate, err := parseDateFlag(until)
+					if err != nil {
+						return err
+					}
+					rule.Until = untilDate
+				}
+				newTask.Recur = rule
+			} else if until != "" {
+				return fmt.Errorf("--until requires --recur")
+			}
 
 			if err := store.AddTask(newTask); err != nil {
 				return fmt.Errorf("failed to add task: %w", err)
@@ -60,6 +112,24 @@ Example:
 	addCmd.Flags().StringVarP(&description, "description", "d", "", "Task description.")
 	addCmd.Flags().StringVarP(&project, "project", "p", "work", "Project the task belongs to. For example work or private.")
 	addCmd.Flags().IntVarP(&priority, "priority", "P", 1, "Task priority (1=Low, 2=Medium, 3=High)")
-	addCmd.Flags().StringVar(&dueDate, "due", "", "Due date (format: YYYY-MM-DD)")
+	addCmd.Flags().StringVar(&dueDate, "due", "", "Due date, e.g. 2025-06-03, \"tomorrow 14:00\", fri, \"in 3 days\", eow (default: none)")
+	addCmd.Flags().StringVar(&parent, "parent", "", "ID or UUID prefix of the parent task, making this a subtask")
+	addCmd.Flags().StringSliceVar(&depends, "depends", nil, "IDs or UUID prefixes of tasks that must be completed first, e.g. 4,5")
+	addCmd.Flags().StringVar(&recur, "recur", "", "Repeat the task: daily, weekly[:mon,fri], monthly[:15], every N days or an RRULE")
+	addCmd.Flags().StringVar(&until, "until", "", "Last date a recurring task may be due")
+	addCmd.Flags().StringSliceVar(&tags, "tag", nil, "Tag to add (repeatable, or use +tag in the title)")
 	return addCmd
 }
+
+// parseDateFlag parses a date given on the command line relative to now.
+// "none" yields the zero time, meaning no date.
+func parseDateFlag(value string) (time.Time, error) {
+	if dateparse.IsNone(value) {
+		return time.Time{}, nil
+	}
+	parsed, err := dateparse.Parse(value, time.Now())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date: %w", err)
+	}
+	return parsed, nil
+}
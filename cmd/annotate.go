@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kevin7254/task/store"
+	"github.com/spf13/cobra"
+)
+
+// NewAnnotateCmd creates the 'annotate' command, which adds a timestamped
+// note to a task.
+func NewAnnotateCmd(taskStore store.TaskRepository) *cobra.Command {
+	return &cobra.Command{
+		Use:   "annotate ID TEXT",
+		Short: "Add a note to a task",
+		Long: `Add a timestamped note to a task. Unlike the description, which is
+replaced when edited, annotations build up a history of the task. "task show"
+lists them oldest first, and filters search them like the title.
+
+Examples:
+  task annotate 3 "Called the supplier, waiting for a quote"
+  task annotate 3 Quote received`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			task, resolveErr := resolveTask(taskStore, args[0])
+			if resolveErr != nil {
+				return resolveErr
+			}
+			id := task.ID
+
+			if _, err := task.Annotate(strings.Join(args[1:], " "), time.Now()); err != nil {
+				return err
+			}
+			if err := taskStore.UpdateTask(task); err != nil {
+				return fmt.Errorf("failed to update task %d: %w", id, err)
+			}
+			cmd.Printf("Annotated task %d: %s\n", id, task.Title)
+			return nil
+		},
+	}
+}
+
+// NewDenotateCmd creates the 'denotate' command, which removes a note from a
+// task.
+func NewDenotateCmd(taskStore store.TaskRepository) *cobra.Command {
+	return &cobra.Command{
+		Use:   "denotate ID [NUMBER | TEXT]",
+		Short: "Remove a note from a task",
+		Long: `Remove an annotation from a task. The annotation is given by its number in
+"task show", by its text, or by part of its text that no other annotation
+contains. Without one, the latest annotation is removed.
+
+Examples:
+  task denotate 3           # Remove the latest note of task 3
+  task denotate 3 2         # Remove the second note
+  task denotate 3 supplier  # Remove the note mentioning the supplier`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			task, resolveErr := resolveTask(taskStore, args[0])
+			if resolveErr != nil {
+				return resolveErr
+			}
+			id := task.ID
+
+			match := strings.Join(args[1:], " ")
+			index, numberErr := strconv.Atoi(match)
+			if numberErr == nil {
+				index--
+			} else {
+				found, err := task.FindAnnotation(match)
+				if err != nil {
+					return fmt.Errorf("task %d: %w", id, err)
+				}
+				index = found
+			}
+			removed, removeErr := task.RemoveAnnotation(index)
+			if removeErr != nil {
+				return fmt.Errorf("task %d: %w", id, removeErr)
+			}
+			if err := taskStore.UpdateTask(task); err != nil {
+				return fmt.Errorf("failed to update task %d: %w", id, err)
+			}
+			cmd.Printf("Removed annotation from task %d: %s\n", id, removed.Description)
+			return nil
+		},
+	}
+}
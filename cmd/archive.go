@@ -0,0 +1,278 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/kevin7254/task/model"
+	"github.com/kevin7254/task/query"
+	"github.com/kevin7254/task/store"
+	"github.com/spf13/cobra"
+)
+
+// NewArchiveCmd creates the 'archive' command, which moves done and cancelled
+// tasks out of the task list into the archive.
+func NewArchiveCmd(taskStore store.TaskRepository) *cobra.Command {
+	var olderThan int
+	var dryRun bool
+	cobraCmd := &cobra.Command{
+		Use:   "archive [ID...|FILTER]",
+		Short: "Move done and cancelled tasks to the archive",
+		Long: `Move done and cancelled tasks out of the task list into a separate archive,
+so that old tasks no longer slow down every command. Archived tasks keep their
+ID and UUID; "task list --archived" lists them, "task show" finds them and
+"task unarchive" brings them back.
+
+Without arguments, every done and cancelled task is archived; --older-than
+only archives those closed at least that many days ago. A filter archives the
+closed tasks that match it. Tasks that open tasks still refer to, as a
+dependency or as a parent, stay in the task list. Setting archive.after_days
+in the config archives closed tasks automatically once they are that many days
+old.
+
+Archiving is not recorded in the undo history; "task unarchive" reverses it.
+
+Examples:
+  task archive                  # Archive every done and cancelled task
+  task archive --older-than 30  # Archive tasks closed more than 30 days ago
+  task archive project:old      # Archive the closed tasks of a project
+  task archive 4 7              # Archive tasks 4 and 7
+  task archive --dry-run        # Show what would be archived`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if olderThan < 0 {
+				return fmt.Errorf("--older-than cannot be negative")
+			}
+			if !cmd.Flags().Changed("older-than") {
+				olderThan = configOf(cmd).Archive.AfterDays
+			}
+			now := time.Now()
+
+			allTasks := taskStore.ListAllTasks()
+			var tasks []*model.Task
+			explicit := len(args) > 0 && !slices.ContainsFunc(args, func(arg string) bool { return !isTaskRef(arg) })
+			if explicit {
+				for _, arg := range args {
+					task, err := resolveTask(taskStore, arg)
+					if err != nil {
+						return err
+					}
+					if !task.IsClosed() {
+						return fmt.Errorf("task %d is %s; only done and cancelled tasks can be archived", task.ID, task.State())
+					}
+					if reason := archiveBlocker(task, allTasks, taskStore.GetTaskByID); reason != "" {
+						return fmt.Errorf("task %d cannot be archived: %s", task.ID, reason)
+					}
+					tasks = append(tasks, task)
+				}
+			} else {
+				candidates := closedBefore(allTasks, now.AddDate(0, 0, -olderThan))
+				if len(args) > 0 {
+					expr, err := parseQuery(args)
+					if err != nil {
+						return err
+					}
+					candidates = query.Filter(candidates, expr, taskStore.GetTaskByID)
+				}
+				for _, task := range candidates {
+					if reason := archiveBlocker(task, allTasks, taskStore.GetTaskByID); reason != "" {
+						cmd.Printf("Kept task %d: %s\n", task.ID, reason)
+						continue
+					}
+					tasks = append(tasks, task)
+				}
+			}
+			if len(tasks) == 0 {
+				cmd.Println("No tasks to archive.")
+				return nil
+			}
+
+			if dryRun {
+				for _, task := range tasks {
+					cmd.Printf("Would archive task %d: %s\n", task.ID, task.Title)
+				}
+				return nil
+			}
+
+			archive, archiveErr := archiveOf(cmd)
+			if archiveErr != nil {
+				return archiveErr
+			}
+			for _, task := range tasks {
+				if err := archiveTask(taskStore, archive, task, now); err != nil {
+					return err
+				}
+				cmd.Printf("Archived task %d: %s\n", task.ID, task.Title)
+			}
+			return nil
+		},
+	}
+	cobraCmd.Flags().IntVar(&olderThan, "older-than", 0, "Only archive tasks closed at least this many days ago (default archive.after_days)")
+	cobraCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show which tasks would be archived without moving them")
+	return acceptFilterArgs(taskStore, cobraCmd)
+}
+
+// NewUnarchiveCmd creates the 'unarchive' command, which moves archived tasks
+// back into the task list.
+func NewUnarchiveCmd(taskStore store.TaskRepository) *cobra.Command {
+	return &cobra.Command{
+		Use:   "unarchive ID [ID...]",
+		Short: "Move archived task(s) back to the task list",
+		Long: `Move archived tasks back into the task list under their original ID. They
+keep their status; "task edit ID --reopen" makes them pending again. Tasks
+brought back are not archived automatically again.
+
+Examples:
+  task unarchive 4      # Bring task 4 back
+  task unarchive 4 7    # Bring tasks 4 and 7 back`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			archive, archiveErr := archiveOf(cmd)
+			if archiveErr != nil {
+				return archiveErr
+			}
+
+			tasks := make([]*model.Task, 0, len(args))
+			for _, arg := range args {
+				task, err := resolveTask(archive, arg)
+				if err != nil {
+					return fmt.Errorf("archive: %w", err)
+				}
+				if taskStore.GetTaskByID(task.ID) != nil {
+					return fmt.Errorf("task %d is both in the task list and in the archive", task.ID)
+				}
+				tasks = append(tasks, task)
+			}
+
+			for _, task := range tasks {
+				if err := unrecorded(taskStore).RestoreTask(task); err != nil {
+					return fmt.Errorf("failed to restore task %d: %w", task.ID, err)
+				}
+				if err := archive.DeleteTask(task.ID); err != nil {
+					return fmt.Errorf("failed to remove task %d from the archive: %w", task.ID, err)
+				}
+				cmd.Printf("Unarchived task %d: %s\n", task.ID, task.Title)
+			}
+			return nil
+		},
+	}
+}
+
+// autoArchive archives the tasks that were closed more than
+// archive.after_days ago, before cmd runs. Problems are reported as warnings
+// so that they never stop the command itself.
+func autoArchive(taskStore store.TaskRepository, cmd *cobra.Command) {
+	days := configOf(cmd).Archive.AfterDays
+	if days <= 0 || !hasArchive(cmd) {
+		return
+	}
+	// Subcommands such as "backup list" count as their parent command.
+	name := cmd.Name()
+	if cmd.HasParent() && cmd.Parent().HasParent() {
+		name = cmd.Parent().Name()
+	}
+	switch name {
+	case "archive", "unarchive", "undo", "redo", "migrate", "clear", "backup", "restore", "help", "completion":
+		return
+	}
+
+	now := time.Now()
+	allTasks := taskStore.ListAllTasks()
+	var tasks []*model.Task
+	for _, task := range closedBefore(allTasks, now.AddDate(0, 0, -days)) {
+		if task.ArchivedAt.IsZero() && archiveBlocker(task, allTasks, taskStore.GetTaskByID) == "" {
+			tasks = append(tasks, task)
+		}
+	}
+	if len(tasks) == 0 {
+		return
+	}
+
+	archive, err := archiveOf(cmd)
+	if err != nil {
+		cmd.PrintErrf("Warning: %v\n", err)
+		return
+	}
+	for _, task := range tasks {
+		if err := archiveTask(taskStore, archive, task, now); err != nil {
+			cmd.PrintErrf("Warning: %v\n", err)
+			return
+		}
+	}
+	cmd.PrintErrf("Archived %d task(s) closed more than %d days ago.\n", len(tasks), days)
+}
+
+// closedBefore returns the done and cancelled tasks that were closed before
+// cutoff, in ID order.
+func closedBefore(tasks []*model.Task, cutoff time.Time) []*model.Task {
+	var closed []*model.Task
+	for _, task := range tasks {
+		if task.IsClosed() && !task.CompletedAt.After(cutoff) {
+			closed = append(closed, task)
+		}
+	}
+	slices.SortFunc(closed, func(a, b *model.Task) int { return a.ID - b.ID })
+	return closed
+}
+
+// archiveBlocker explains why task has to stay in the task list, because an
+// open task still refers to it. It returns "" if the task can be archived.
+func archiveBlocker(task *model.Task, tasks []*model.Task, lookup model.TaskLookup) string {
+	if task.ParentID != 0 {
+		if parent := lookup(task.ParentID); parent != nil && !parent.IsClosed() {
+			return fmt.Sprintf("its parent task %d is open", parent.ID)
+		}
+	}
+	for _, other := range tasks {
+		if other.IsClosed() {
+			continue
+		}
+		if other.ParentID == task.ID {
+			return fmt.Sprintf("its subtask %d is open", other.ID)
+		}
+		if slices.Contains(other.DependsOn, task.ID) {
+			return fmt.Sprintf("open task %d depends on it", other.ID)
+		}
+	}
+	return ""
+}
+
+// archiveTask moves task from taskStore into archive, marking it as archived
+// at now.
+func archiveTask(taskStore store.TaskRepository, archive store.TaskRepository, task *model.Task, now time.Time) error {
+	task.ArchivedAt = now
+	var err error
+	if archive.GetTaskByID(task.ID) != nil {
+		err = archive.UpdateTask(task)
+	} else {
+		err = archive.RestoreTask(task)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to archive task %d: %w", task.ID, err)
+	}
+	if err := unrecorded(taskStore).DeleteTask(task.ID); err != nil {
+		return fmt.Errorf("failed to remove archived task %d: %w", task.ID, err)
+	}
+	return nil
+}
+
+// unrecorded returns the store to make changes through that bypass the undo
+// history. Moving tasks between the task list and the archive is not
+// recorded, as undoing half of such a move would leave a task in both.
+func unrecorded(taskStore store.TaskRepository) store.TaskRepository {
+	if recorder, ok := taskStore.(*store.Recorder); ok {
+		return recorder.Unrecorded()
+	}
+	return taskStore
+}
+
+// lookupWithArchive returns a lookup that finds tasks in the task list first
+// and in the archive otherwise.
+func lookupWithArchive(taskStore store.TaskRepository, archive store.TaskRepository) model.TaskLookup {
+	return func(id int) *model.Task {
+		if task := taskStore.GetTaskByID(id); task != nil {
+			return task
+		}
+		return archive.GetTaskByID(id)
+	}
+}
@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/kevin7254/task/config"
+	"github.com/kevin7254/task/store"
+	"github.com/spf13/cobra"
+)
+
+// errNoBackupDir is returned by the backup commands when the root command
+// was created without WithConfig.
+var errNoBackupDir = errors.New("no backup directory is configured")
+
+// NewBackupCmd creates the 'backup' command, which takes snapshots of the
+// tasks.
+func NewBackupCmd(taskStore store.TaskRepository) *cobra.Command {
+	backupCmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Save a snapshot of all tasks",
+		Long: `Save a compressed snapshot of all tasks to the backup directory
+(backup.dir in the config, ~/.task/backups by default). Snapshots are also
+taken automatically before "task remove", "task clear" and "task restore".
+Only the newest backup.keep snapshots are kept (20 by default).
+
+"task restore" brings a snapshot back.
+
+Examples:
+  task backup        # Save a snapshot now
+  task backup list   # List the snapshots with their task counts`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, ok := backupConfigOf(cmd); !ok {
+				return errNoBackupDir
+			}
+			path, err := takeSnapshot(cmd, taskStore)
+			if err != nil {
+				return err
+			}
+			cmd.Printf("Saved a snapshot of %d tasks to %s\n", len(taskStore.ListAllTasks()), path)
+			return nil
+		},
+	}
+	backupCmd.AddCommand(newBackupListCmd())
+	return backupCmd
+}
+
+// newBackupListCmd creates the 'backup list' command.
+func newBackupListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List snapshots, newest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, ok := backupConfigOf(cmd)
+			if !ok {
+				return errNoBackupDir
+			}
+			snapshots, err := store.ListSnapshots(cfg.Dir)
+			if err != nil {
+				return err
+			}
+			if len(snapshots) == 0 {
+				cmd.Println("No snapshots found.")
+				return nil
+			}
+
+			rows := make([][]string, len(snapshots))
+			for i, snapshot := range snapshots {
+				count := "invalid"
+				if tasks, readErr := store.ReadSnapshot(snapshot.Path); readErr == nil {
+					count = strconv.Itoa(len(tasks))
+				}
+				rows[i] = []string{snapshot.Name, snapshot.Taken.Format("2006-01-02 15:04:05"), count, formatSize(snapshot.Size)}
+			}
+			dm := NewDisplayManager(cmd.OutOrStdout())
+			return dm.renderTable([]string{"Snapshot", "Taken", "Tasks", "Size"}, rows)
+		},
+	}
+}
+
+// NewRestoreCmd creates the 'restore' command, which replaces all tasks with
+// the contents of a snapshot.
+func NewRestoreCmd(taskStore store.TaskRepository) *cobra.Command {
+	var yes bool
+	cobraCmd := &cobra.Command{
+		Use:   "restore SNAPSHOT",
+		Short: "Replace all tasks with a snapshot",
+		Long: `Replace all tasks with the tasks saved in a snapshot. SNAPSHOT is a name
+listed by "task backup list" or the path to a snapshot file. The snapshot is
+checked before anything changes, and the current tasks are saved to a new
+snapshot first. Archived tasks are not affected.
+
+The restore is recorded like any other change, so "task undo" reverts it.
+
+Examples:
+  task backup list                         # Find the snapshot to restore
+  task restore tasks-20250603-093000       # Restore it
+  task restore ~/tasks-20250603-093000.json.gz --yes`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, _ := backupConfigOf(cmd)
+			path, findErr := store.FindSnapshot(cfg.Dir, args[0])
+			if findErr != nil {
+				return findErr
+			}
+			tasks, readErr := store.ReadSnapshot(path)
+			if readErr != nil {
+				return readErr
+			}
+
+			current, listErr := store.ListTasks(taskStore)
+			if listErr != nil {
+				return listErr
+			}
+			if !yes && !confirm(cmd, fmt.Sprintf("Replace the %d current tasks with the %d tasks in %s?", len(current), len(tasks), args[0])) {
+				cmd.Println("Aborted; no tasks were changed.")
+				return nil
+			}
+
+			saved, snapshotErr := takeSnapshot(cmd, taskStore)
+			if snapshotErr != nil {
+				return snapshotErr
+			}
+			if saved != "" {
+				cmd.Printf("Saved a snapshot of the current tasks to %s\n", saved)
+			}
+			if err := store.ReplaceTasks(taskStore, tasks); err != nil {
+				return err
+			}
+			cmd.Printf("Restored %d tasks from %s\n", len(tasks), path)
+			return nil
+		},
+	}
+	cobraCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Don't ask for confirmation")
+	return cobraCmd
+}
+
+// takeSnapshot writes a snapshot of every task in taskStore to the
+// configured backup directory, removes the snapshots beyond backup.keep and
+// returns the new snapshot's path. Without a backup directory no snapshot is
+// taken and the path is empty.
+func takeSnapshot(cmd *cobra.Command, taskStore store.TaskRepository) (string, error) {
+	cfg, ok := backupConfigOf(cmd)
+	if !ok {
+		return "", nil
+	}
+	path, err := store.WriteSnapshot(cfg.Dir, taskStore, time.Now())
+	if err != nil {
+		return "", fmt.Errorf("failed to back up the tasks: %w", err)
+	}
+	if _, pruneErr := store.PruneSnapshots(cfg.Dir, cfg.Keep); pruneErr != nil {
+		cmd.PrintErrf("Warning: %v\n", pruneErr)
+	}
+	return path, nil
+}
+
+// backupConfigOf returns the backup settings cmd runs with. Unlike the other
+// settings they have no fallback: without WithConfig there is no task
+// directory to put snapshots in, and ok is false.
+func backupConfigOf(cmd *cobra.Command) (cfg config.BackupConfig, ok bool) {
+	if ctx := cmd.Context(); ctx != nil {
+		if cfg, ok := ctx.Value(configKey{}).(*config.Config); ok && cfg.Backup.Dir != "" {
+			return cfg.Backup, true
+		}
+	}
+	return config.BackupConfig{}, false
+}
+
+// formatSize renders a file size such as "840 B" or "12.5 KB".
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value := float64(size) / unit
+	for _, suffix := range []string{"KB", "MB"} {
+		if value < unit {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+		value /= unit
+	}
+	return fmt.Sprintf("%.1f GB", value)
+}
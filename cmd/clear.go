@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/kevin7254/task/model"
+	"github.com/kevin7254/task/store"
+	"github.com/spf13/cobra"
+)
+
+// NewClearCmd creates the 'clear' command, which deletes many tasks at once.
+func NewClearCmd(taskStore store.TaskRepository) *cobra.Command {
+	var completed, all, dryRun, yes bool
+	var project string
+	cobraCmd := &cobra.Command{
+		Use:   "clear [FILTER]",
+		Short: "Delete many tasks at once",
+		Long: `Delete many tasks at once. Choose which ones:
+
+  --completed       done and cancelled tasks
+  --project NAME    the tasks of a project
+  FILTER            the tasks matching a filter (see "task list --help")
+  --all             every task
+
+--completed, --project and a filter can be combined, and then all have to
+match. Unlike "task remove", a project or filter matches done and cancelled
+tasks too.
+
+The tasks are listed and you are asked before they are deleted, unless --yes
+is given; --dry-run only lists them. Before anything is deleted, a snapshot of
+all tasks is saved to the backup directory, which "task restore" brings back
+(see "task backup"). "task undo" also brings them back. Other tasks that
+depended on a deleted task have that dependency removed, and subtasks of a
+deleted task become top-level tasks.
+
+Examples:
+  task clear --completed         # Delete done and cancelled tasks
+  task clear --project old       # Delete every task in the 'old' project
+  task clear +someday --dry-run  # Show which tasks tagged someday would go
+  task clear --all --yes         # Delete every task without asking`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if all && (completed || project != "" || len(args) > 0) {
+				return fmt.Errorf("--all cannot be combined with --completed, --project or a filter")
+			}
+			if !all && !completed && project == "" && len(args) == 0 {
+				return fmt.Errorf("choose what to clear: --completed, --project, a filter or --all")
+			}
+
+			opts := &listOptions{projectFilter: project, showCompleted: true}
+			if completed {
+				opts.statuses = []model.Status{model.StatusDone, model.StatusCancelled}
+			}
+			if len(args) > 0 {
+				expr, err := parseQuery(args)
+				if err != nil {
+					return err
+				}
+				opts.filter = expr
+			}
+			allTasks, listErr := store.ListTasks(taskStore)
+			if listErr != nil {
+				return listErr
+			}
+			tasks := filterTasks(allTasks, opts, taskStore.GetTaskByID)
+			slices.SortFunc(tasks, func(a, b *model.Task) int { return a.ID - b.ID })
+			if len(tasks) == 0 {
+				cmd.Println("No tasks to clear.")
+				return nil
+			}
+
+			if dryRun {
+				for _, task := range tasks {
+					cmd.Printf("Would delete task %d: %s\n", task.ID, task.Title)
+				}
+				return nil
+			}
+			if !yes {
+				for _, task := range tasks {
+					cmd.Printf("  %d: %s\n", task.ID, task.Title)
+				}
+				if !confirm(cmd, fmt.Sprintf("Delete %d tasks?", len(tasks))) {
+					cmd.Println("Aborted; no tasks were deleted.")
+					return nil
+				}
+			}
+
+			snapshot, snapshotErr := takeSnapshot(cmd, taskStore)
+			if snapshotErr != nil {
+				return snapshotErr
+			}
+			if snapshot != "" {
+				cmd.Printf("Saved a snapshot of the tasks to %s\n", snapshot)
+			}
+			deleted := make([]int, 0, len(tasks))
+			for _, task := range tasks {
+				if err := taskStore.DeleteTask(task.ID); err != nil {
+					return fmt.Errorf("failed to delete task %d: %w", task.ID, err)
+				}
+				deleted = append(deleted, task.ID)
+			}
+			cmd.Printf("Deleted %d tasks.\n", len(deleted))
+
+			for _, task := range taskStore.ListAllTasks() {
+				dependedOn := false
+				for _, id := range deleted {
+					dependedOn = task.RemoveDependency(id) || dependedOn
+				}
+				orphaned := slices.Contains(deleted, task.ParentID)
+				if orphaned {
+					task.ParentID = 0
+				}
+				if !dependedOn && !orphaned {
+					continue
+				}
+				if err := taskStore.UpdateTask(task); err != nil {
+					return fmt.Errorf("failed to update task %d: %w", task.ID, err)
+				}
+				if dependedOn {
+					cmd.Printf("Removed dependencies on deleted tasks from task %d: %s\n", task.ID, task.Title)
+				}
+				if orphaned {
+					cmd.Printf("Removed the deleted parent from task %d: %s\n", task.ID, task.Title)
+				}
+			}
+			return nil
+		},
+	}
+	cobraCmd.Flags().BoolVar(&completed, "completed", false, "Delete done and cancelled tasks")
+	cobraCmd.Flags().StringVarP(&project, "project", "p", "", "Delete the tasks of this project")
+	cobraCmd.Flags().BoolVar(&all, "all", false, "Delete every task")
+	cobraCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show which tasks would be deleted without deleting them")
+	cobraCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Don't ask for confirmation")
+	return acceptFilterArgs(taskStore, cobraCmd)
+}
@@ -2,142 +2,150 @@ package cmd_test
 
 import (
 	"bytes"
+	"database/sql"
+	"encoding/json"
 	"github.com/kevin7254/task/cmd"
+	"github.com/kevin7254/task/config"
 	"github.com/kevin7254/task/model"
 	"github.com/kevin7254/task/store"
 	"github.com/spf13/cobra"
+	"io"
+	"os"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestDoCmd(t *testing.T) {
-	testStore, cobraCmd := beforeTests(t)
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, cobraCmd *cobra.Command) {
+		task := &model.Task{Title: "Original Task Title"}
+		err := testStore.AddTask(task)
+		if err != nil {
+			t.Fatalf("Failed to add initial task: %v", err)
+		}
+		taskID := strconv.Itoa(task.ID)
 
-	task := &model.Task{Title: "Original Task Title"}
-	err := testStore.AddTask(task)
-	if err != nil {
-		t.Fatalf("Failed to add initial task: %v", err)
-	}
-	taskID := strconv.Itoa(task.ID)
-
-	doOutput := "Completed task "
-	assertCommand(t, cobraCmd, "do", taskID, doOutput)
+		doOutput := "Completed task "
+		assertCommand(t, cobraCmd, "do", taskID, doOutput)
 
-	updatedTask := testStore.GetTaskByID(task.ID)
-	if updatedTask == nil {
-		t.Fatalf("Task with ID %d should exist but was not found.", task.ID)
-	}
-	if updatedTask.CompletedAt.IsZero() {
-		t.Errorf("Expected to be completed %q", updatedTask.CompletedAt)
-	}
+		updatedTask := testStore.GetTaskByID(task.ID)
+		if updatedTask == nil {
+			t.Fatalf("Task with ID %d should exist but was not found.", task.ID)
+		}
+		if updatedTask.CompletedAt.IsZero() {
+			t.Errorf("Expected to be completed %q", updatedTask.CompletedAt)
+		}
+	})
 }
 
 func TestAddCmd(t *testing.T) {
-	testStore, cobraCmd := beforeTests(t)
-	taskName := "My New Test Task"
-	output := "Successfully added task: "
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, cobraCmd *cobra.Command) {
+		taskName := "My New Test Task"
+		output := "Successfully added task: "
 
-	assertCommand(t, cobraCmd, "add", taskName, output)
-	assertListTasks(t, testStore, taskName)
+		assertCommand(t, cobraCmd, "add", taskName, output)
+		assertListTasks(t, testStore, taskName)
+	})
 }
 
 func TestEditCmd(t *testing.T) {
-	testStore, _ := beforeTests(t)
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, _ *cobra.Command) {
+		// Create a sample task in the store.
+		task := &model.Task{Title: "Original Task Title"}
+		err := testStore.AddTask(task)
+		if err != nil {
+			t.Fatalf("Failed to add initial task: %v", err)
+		}
+		taskID := strconv.Itoa(task.ID)
 
-	// Create a sample task in the store.
-	task := &model.Task{Title: "Original Task Title"}
-	err := testStore.AddTask(task)
-	if err != nil {
-		t.Fatalf("Failed to add initial task: %v", err)
-	}
-	taskID := strconv.Itoa(task.ID)
+		editCmd := cmd.NewEditCmd(testStore)
+		newTitle := "Updated Task Title"
+		args := []string{taskID, "--title", newTitle}
+		output, execErr := executeCommand(editCmd, args...)
+		if execErr != nil {
+			t.Fatalf("Expected no error, got %v. Output: %s", execErr, output)
+		}
+		if !strings.Contains(output, "Updated task with ID") {
+			t.Errorf("Expected success message, got: %q", output)
+		}
 
-	editCmd := cmd.NewEditCmd(testStore)
-	newTitle := "Updated Task Title"
-	args := []string{taskID, "--title", newTitle}
-	output, execErr := executeCommand(editCmd, args...)
-	if execErr != nil {
-		t.Fatalf("Expected no error, got %v. Output: %s", execErr, output)
-	}
-	if !strings.Contains(output, "Updated task with ID") {
-		t.Errorf("Expected success message, got: %q", output)
-	}
-
-	updatedTask := testStore.GetTaskByID(task.ID)
-	if updatedTask == nil {
-		t.Fatalf("Task with ID %d should exist but was not found.", task.ID)
-	}
-	if updatedTask.Title != newTitle {
-		t.Errorf("Expected task title to be %q, got %q", newTitle, updatedTask.Title)
-	}
+		updatedTask := testStore.GetTaskByID(task.ID)
+		if updatedTask == nil {
+			t.Fatalf("Task with ID %d should exist but was not found.", task.ID)
+		}
+		if updatedTask.Title != newTitle {
+			t.Errorf("Expected task title to be %q, got %q", newTitle, updatedTask.Title)
+		}
 
-	invalidID := "abc"
-	args = []string{invalidID, "--title", newTitle}
-	output, execErr = executeCommand(editCmd, args...)
-	if execErr == nil || !strings.Contains(output, "invalid task ID") {
-		t.Errorf("Expected error for invalid task ID. Output: %s", output)
-	}
+		invalidID := "abc"
+		args = []string{invalidID, "--title", newTitle}
+		output, execErr = executeCommand(editCmd, args...)
+		if execErr == nil || !strings.Contains(output, "invalid task ID") {
+			t.Errorf("Expected error for invalid task ID. Output: %s", output)
+		}
 
-	nonExistentID := strconv.Itoa(task.ID + 999)
-	args = []string{nonExistentID, "--title", newTitle}
-	output, execErr = executeCommand(editCmd, args...)
-	if execErr == nil || !strings.Contains(output, "task with ID") {
-		t.Errorf("Expected error for non-existent task ID. Output: %s", output)
-	}
+		nonExistentID := strconv.Itoa(task.ID + 999)
+		args = []string{nonExistentID, "--title", newTitle}
+		output, execErr = executeCommand(editCmd, args...)
+		if execErr == nil || !strings.Contains(output, "task with ID") {
+			t.Errorf("Expected error for non-existent task ID. Output: %s", output)
+		}
+	})
 }
 
 func TestAddCmd_EmptyTaskName(t *testing.T) {
-	_, cobraCmd := beforeTests(t)
+	forEachStore(t, func(t *testing.T, _ store.TaskRepository, cobraCmd *cobra.Command) {
+		args := []string{"add"} // No task name provided
+		output, err := executeCommand(cobraCmd, args...)
 
-	args := []string{"add"} // No task name provided
-	output, err := executeCommand(cobraCmd, args...)
-
-	if err == nil {
-		t.Error("Expected error for empty task name, but got nil")
-	}
+		if err == nil {
+			t.Error("Expected error for empty task name, but got nil")
+		}
 
-	if !strings.Contains(output, "task name cannot be empty") {
-		t.Errorf("Expected error message about empty task name, but got %q", output)
-	}
+		if !strings.Contains(output, "task name cannot be empty") {
+			t.Errorf("Expected error message about empty task name, but got %q", output)
+		}
+	})
 }
 
 func TestAddCmd_WithFlags(t *testing.T) {
-	testStore, cobraCmd := beforeTests(t)
-
-	args := []string{
-		"add",
-		"Test Task With Flags",
-		"--description", "This is a test description",
-		"--project", "test-project",
-		"--priority", "2",
-		"--due", "2023-12-31",
-	}
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, cobraCmd *cobra.Command) {
+		args := []string{
+			"add",
+			"Test Task With Flags",
+			"--description", "This is a test description",
+			"--project", "test-project",
+			"--priority", "2",
+			"--due", "2023-12-31",
+		}
 
-	output, execErr := executeCommand(cobraCmd, args...)
-	if execErr != nil {
-		t.Fatalf("executeCommand failed: %v. Output: %s", execErr, output)
-	}
+		output, execErr := executeCommand(cobraCmd, args...)
+		if execErr != nil {
+			t.Fatalf("executeCommand failed: %v. Output: %s", execErr, output)
+		}
 
-	tasks := testStore.ListAllTasks()
-	if len(tasks) != 1 {
-		t.Fatalf("Expected 1 task in store, found %d", len(tasks))
-	}
+		tasks := testStore.ListAllTasks()
+		if len(tasks) != 1 {
+			t.Fatalf("Expected 1 task in store, found %d", len(tasks))
+		}
 
-	task := tasks[0]
-	if task.Title != "Test Task With Flags" {
-		t.Errorf("Expected task title to be 'Test Task With Flags', got %q", task.Title)
-	}
-	if task.Description != "This is a test description" {
-		t.Errorf("Expected task description to be set, got %q", task.Description)
-	}
-	if task.Project != "test-project" {
-		t.Errorf("Expected task project to be 'test-project', got %q", task.Project)
-	}
-	if task.Priority != 2 {
-		t.Errorf("Expected task priority to be 2, got %d", task.Priority)
-	}
+		task := tasks[0]
+		if task.Title != "Test Task With Flags" {
+			t.Errorf("Expected task title to be 'Test Task With Flags', got %q", task.Title)
+		}
+		if task.Description != "This is a test description" {
+			t.Errorf("Expected task description to be set, got %q", task.Description)
+		}
+		if task.Project != "test-project" {
+			t.Errorf("Expected task project to be 'test-project', got %q", task.Project)
+		}
+		if task.Priority != 2 {
+			t.Errorf("Expected task priority to be 2, got %d", task.Priority)
+		}
+	})
 }
 
 func assertCommand(t testing.TB, cobraCmd *cobra.Command, inputCmd, taskName, output string) {
@@ -163,7 +171,7 @@ func assertErr(t testing.TB, output string, execErr error) {
 	}
 }
 
-func assertListTasks(t testing.TB, store *store.JsonStore, taskName string) {
+func assertListTasks(t testing.TB, store store.TaskRepository, taskName string) {
 	t.Helper()
 	tasks := store.ListAllTasks()
 	if len(tasks) != 1 {
@@ -186,18 +194,1367 @@ func executeCommand(root *cobra.Command, args ...string) (output string, err err
 	return buf.String(), err
 }
 
-func beforeTests(t *testing.T) (store *store.JsonStore, cobraCmd *cobra.Command) {
-	store = setupTestStorage(t)
-	return store, cmd.NewRootCmd(store)
+// storeBackends lists every TaskRepository implementation the commands are
+// tested against.
+var storeBackends = []struct {
+	name string
+	open func(filename string) (store.TaskRepository, error)
+}{
+	{"json", func(filename string) (store.TaskRepository, error) { return store.NewJsonStore(filename) }},
+	{"sqlite", func(filename string) (store.TaskRepository, error) { return store.NewSQLiteStore(filename) }},
 }
 
-func setupTestStorage(t *testing.T) *store.JsonStore {
+// forEachStore runs fn once per storage backend, each with a fresh store and
+// root command.
+func forEachStore(t *testing.T, fn func(t *testing.T, testStore store.TaskRepository, cobraCmd *cobra.Command)) {
+	t.Helper()
+	for _, backend := range storeBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			testStore := setupTestStorage(t, backend.open)
+			fn(t, testStore, cmd.NewRootCmd(testStore))
+		})
+	}
+}
+
+func setupTestStorage(t *testing.T, open func(filename string) (store.TaskRepository, error)) store.TaskRepository {
 	tempDir := t.TempDir()
-	testStorageFile := filepath.Join(tempDir, "test_tasks.json")
+	testStorageFile := filepath.Join(tempDir, "test_tasks")
 
-	s, err := store.NewJsonStore(testStorageFile)
+	s, err := open(testStorageFile)
 	if err != nil {
 		t.Fatalf("Failed to create test store: %v", err)
 	}
+	if closer, ok := s.(io.Closer); ok {
+		t.Cleanup(func() { _ = closer.Close() })
+	}
 	return s
 }
+
+func TestMigrateCmd_DryRun(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "tasks.json")
+	legacy := `{"1": {"id": 1, "title": "Legacy task"}}`
+	if err := os.WriteFile(filename, []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to write legacy file: %v", err)
+	}
+	testStore, err := store.NewJsonStore(filename)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	cobraCmd := cmd.NewRootCmd(testStore)
+
+	output, execErr := executeCommand(cobraCmd, "migrate", "--dry-run")
+	assertErr(t, output, execErr)
+	if !strings.Contains(output, "Would migrate 1 task(s) from schema version 1") {
+		t.Errorf("Expected dry-run report, got %q", output)
+	}
+	if data, _ := os.ReadFile(filename); string(data) != legacy {
+		t.Errorf("Expected dry run to leave the store file untouched")
+	}
+
+	output, execErr = executeCommand(cmd.NewRootCmd(testStore), "migrate")
+	assertErr(t, output, execErr)
+	if !strings.Contains(output, "Migrated 1 task(s)") {
+		t.Errorf("Expected migration report, got %q", output)
+	}
+	if _, statErr := os.Stat(filename + ".v1.bak"); statErr != nil {
+		t.Errorf("Expected backup of the legacy file: %v", statErr)
+	}
+}
+
+func TestMigrateCmd_SQLite(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "tasks.db")
+	created, err := store.NewSQLiteStore(filename)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	if err := created.AddTask(&model.Task{Title: "Old task"}); err != nil {
+		t.Fatalf("Failed to add task: %v", err)
+	}
+	_ = created.Close()
+
+	// Turn the database into one written before tasks had UUIDs.
+	db, err := sql.Open("sqlite", filename)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	for _, stmt := range []string{`UPDATE tasks SET data = json_remove(data, '$.uuid')`, `PRAGMA user_version = 4`} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to downgrade database: %v", err)
+		}
+	}
+	_ = db.Close()
+
+	testStore := setupTestStorage(t, func(string) (store.TaskRepository, error) { return store.NewSQLiteStore(filename) })
+	output, execErr := executeCommand(cmd.NewRootCmd(testStore), "migrate", "--dry-run")
+	assertErr(t, output, execErr)
+	assertOutputContains(t, "Migrated 1 task(s) from schema version 4 to 5 when the store was opened", output)
+	assertOutputContains(t, "The original was backed up to "+filename+".v4.bak", output)
+	if testStore.GetTaskByID(1).UUID == "" {
+		t.Error("Expected the upgraded task to have a UUID")
+	}
+}
+
+func TestLegacyStore_Changes(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "tasks.json")
+	legacy := `{"1": {"id": 1, "title": "Legacy task"}, "2": {"id": 2, "title": "Other task"}}`
+	if err := os.WriteFile(filename, []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to write legacy file: %v", err)
+	}
+	testStore, err := store.NewJsonStore(filename)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	uuid := testStore.GetTaskByID(1).UUID
+
+	output, execErr := executeCommand(cmd.NewRootCmd(testStore), "edit", "1", "--title", "Renamed")
+	assertErr(t, output, execErr)
+	output, execErr = executeCommand(cmd.NewRootCmd(testStore), "do", "2")
+	assertErr(t, output, execErr)
+	assertOutputContains(t, "Completed task 2", output)
+
+	reopened, err := store.NewJsonStore(filename)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	if task := reopened.GetTaskByID(1); task.Title != "Renamed" || task.UUID != uuid {
+		t.Errorf("Expected task 1 renamed with UUID %s, got %+v", uuid, task)
+	}
+	if !reopened.GetTaskByID(2).IsClosed() {
+		t.Error("Expected task 2 to be done")
+	}
+}
+
+func TestTags(t *testing.T) {
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, cobraCmd *cobra.Command) {
+		output, execErr := executeCommand(cobraCmd, "add", "Review", "deploy", "+Review", "+urgent", "--tag", "work")
+		assertErr(t, output, execErr)
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "add", "Fix build", "+urgent")
+		assertErr(t, output, execErr)
+
+		tasks := testStore.ListAllTasks()
+		if len(tasks) != 2 {
+			t.Fatalf("Expected 2 tasks, found %d", len(tasks))
+		}
+		reviewTask := testStore.GetTaskByID(1)
+		if reviewTask.Title != "Review deploy" {
+			t.Errorf("Expected tags to be stripped from title, got %q", reviewTask.Title)
+		}
+		if want := []string{"review", "urgent", "work"}; strings.Join(reviewTask.Tags, ",") != strings.Join(want, ",") {
+			t.Errorf("Expected tags %v, got %v", want, reviewTask.Tags)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", "--tag", "urgent", "--no-tag", "review")
+		assertErr(t, output, execErr)
+		if !strings.Contains(output, "Fix build") || strings.Contains(output, "Review deploy") {
+			t.Errorf("Expected only 'Fix build' in filtered list, got %q", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "edit", "1", "--untag", "work", "--tag", "blocked")
+		assertErr(t, output, execErr)
+		edited := testStore.GetTaskByID(1)
+		if edited.Title != "Review deploy" {
+			t.Errorf("Expected title to be unchanged when only editing tags, got %q", edited.Title)
+		}
+		if edited.HasTag("work") || !edited.HasTag("blocked") {
+			t.Errorf("Expected tags to be edited, got %v", edited.Tags)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "tags")
+		assertErr(t, output, execErr)
+		if !strings.Contains(output, "+urgent   2") {
+			t.Errorf("Expected urgent to be counted twice, got %q", output)
+		}
+	})
+}
+
+func TestSubtasks(t *testing.T) {
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, cobraCmd *cobra.Command) {
+		parent := &model.Task{Title: "Release"}
+		if err := testStore.AddTask(parent); err != nil {
+			t.Fatalf("Failed to add parent: %v", err)
+		}
+		parentID := strconv.Itoa(parent.ID)
+		for _, title := range []string{"Changelog", "Tag"} {
+			output, execErr := executeCommand(cmd.NewRootCmd(testStore), "add", title, "--parent", parentID)
+			assertErr(t, output, execErr)
+		}
+
+		output, execErr := executeCommand(cmd.NewRootCmd(testStore), "add", "Orphan", "--parent", "999")
+		if execErr == nil || !strings.Contains(output, "parent task with ID 999 not found") {
+			t.Errorf("Expected error for unknown parent, got %q", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", "--view", "tree")
+		assertErr(t, output, execErr)
+		if !strings.Contains(output, "├─ Changelog") || !strings.Contains(output, "└─ Tag") {
+			t.Errorf("Expected subtasks nested in tree view, got %q", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "do", "2")
+		assertErr(t, output, execErr)
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "show", parentID)
+		assertErr(t, output, execErr)
+		if !strings.Contains(output, "Subtasks: 1/2 done") {
+			t.Errorf("Expected subtask progress in show, got %q", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "do", parentID)
+		if execErr == nil || !strings.Contains(output, "open subtask(s)") {
+			t.Errorf("Expected do to refuse a parent with open subtasks, got %q", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "do", parentID, "--cascade")
+		assertErr(t, output, execErr)
+		for _, task := range testStore.ListAllTasks() {
+			if task.CompletedAt.IsZero() {
+				t.Errorf("Expected task %d to be completed by cascade", task.ID)
+			}
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "remove", parentID)
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Removed parent task 1 from task 3: Tag", output)
+		for _, task := range testStore.ListAllTasks() {
+			if task.ParentID != 0 {
+				t.Errorf("Expected task %d not to point at the removed parent", task.ID)
+			}
+		}
+	})
+}
+
+func TestDependencies(t *testing.T) {
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, cobraCmd *cobra.Command) {
+		for _, title := range []string{"Design", "Build"} {
+			output, execErr := executeCommand(cmd.NewRootCmd(testStore), "add", title)
+			assertErr(t, output, execErr)
+		}
+		output, execErr := executeCommand(cmd.NewRootCmd(testStore), "add", "Ship", "--depends", "1,2")
+		assertErr(t, output, execErr)
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", "--ready")
+		assertErr(t, output, execErr)
+		if strings.Contains(output, "Ship") || !strings.Contains(output, "Design") {
+			t.Errorf("Expected blocked task to be hidden from --ready, got %q", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", "--view", "full")
+		assertErr(t, output, execErr)
+		if !strings.Contains(output, "⛔") {
+			t.Errorf("Expected blocked status icon, got %q", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "edit", "1", "--depends", "3")
+		if execErr == nil || !strings.Contains(output, "dependency cycle: 1 -> 3 -> 1") {
+			t.Errorf("Expected a dependency cycle error, got %q", output)
+		}
+		if deps := testStore.GetTaskByID(1).DependsOn; len(deps) != 0 {
+			t.Errorf("Expected rejected edit to leave dependencies untouched, got %v", deps)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "do", "1", "2")
+		assertErr(t, output, execErr)
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", "--ready")
+		assertErr(t, output, execErr)
+		if !strings.Contains(output, "Ship") {
+			t.Errorf("Expected task to be ready once dependencies are done, got %q", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "remove", "2")
+		assertErr(t, output, execErr)
+		if !strings.Contains(output, "Removed dependency on task 2 from task 3") {
+			t.Errorf("Expected dangling dependency cleanup message, got %q", output)
+		}
+		if deps := testStore.GetTaskByID(3).DependsOn; len(deps) != 1 || deps[0] != 1 {
+			t.Errorf("Expected only dependency 1 to remain, got %v", deps)
+		}
+	})
+}
+
+func TestRecurringTasks(t *testing.T) {
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, cobraCmd *cobra.Command) {
+		output, execErr := executeCommand(cobraCmd, "add", "Weekly report", "--due", "2025-06-06", "--recur", "weekly:fri", "--until", "2025-06-13")
+		assertErr(t, output, execErr)
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "do", "1")
+		assertErr(t, output, execErr)
+		if !strings.Contains(output, "Next occurrence of task 1 is task 2, due 2025-06-13") {
+			t.Errorf("Expected next occurrence to be created, got %q", output)
+		}
+		next := testStore.GetTaskByID(2)
+		if next == nil || next.Recur == nil || !next.CompletedAt.IsZero() {
+			t.Fatalf("Expected a pending recurring task 2, got %+v", next)
+		}
+		if testStore.GetTaskByID(1).Recur != nil {
+			t.Errorf("Expected the completed instance to no longer carry the rule")
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "recur")
+		assertErr(t, output, execErr)
+		if !strings.Contains(output, "weekly:fri until 2025-06-13") {
+			t.Errorf("Expected recurring task in recur list, got %q", output)
+		}
+
+		// The next instance would fall after --until, so the series ends.
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "do", "2")
+		assertErr(t, output, execErr)
+		if strings.Contains(output, "Next occurrence") || len(testStore.ListAllTasks()) != 2 {
+			t.Errorf("Expected the series to end at the until date, got %q", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "add", "Daily standup", "--due", "2025-06-02", "--recur", "daily")
+		assertErr(t, output, execErr)
+
+		// Completing through edit continues the series too.
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "edit", "3", "--status", "done")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Next occurrence of task 3 is task 4, due 2025-06-03", output)
+		if testStore.GetTaskByID(3).Recur != nil || testStore.GetTaskByID(4) == nil {
+			t.Errorf("Expected edit --status done to hand the rule to task 4")
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "recur", "stop", "4")
+		assertErr(t, output, execErr)
+		if testStore.GetTaskByID(4).Recur != nil {
+			t.Errorf("Expected recur stop to clear the rule")
+		}
+	})
+}
+
+func TestDueDates(t *testing.T) {
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, cobraCmd *cobra.Command) {
+		output, execErr := executeCommand(cobraCmd, "add", "No deadline")
+		assertErr(t, output, execErr)
+		if due := testStore.GetTaskByID(1).DueDate; !due.IsZero() {
+			t.Errorf("Expected no due date by default, got %s", due)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "add", "Call the bank", "--due", "tomorrow 14:00")
+		assertErr(t, output, execErr)
+		tomorrow := time.Now().AddDate(0, 0, 1)
+		want := time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 14, 0, 0, 0, time.Local)
+		if due := testStore.GetTaskByID(2).DueDate; !due.Equal(want) {
+			t.Errorf("Expected due date %s, got %s", want, due)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "edit", "2", "--due", "none")
+		assertErr(t, output, execErr)
+		if due := testStore.GetTaskByID(2).DueDate; !due.IsZero() {
+			t.Errorf("Expected --due none to clear the due date, got %s", due)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "add", "Bad", "--due", "someday")
+		if execErr == nil || !strings.Contains(output, "invalid date") {
+			t.Errorf("Expected an invalid date error, got %q", output)
+		}
+	})
+}
+
+func TestEditCmd_Fields(t *testing.T) {
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, cobraCmd *cobra.Command) {
+		output, execErr := executeCommand(cobraCmd, "add", "Write report", "-d", "Q3 numbers", "-P", "1")
+		assertErr(t, output, execErr)
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "edit", "1", "--priority", "3", "--project", "home", "--time", "45")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "priority: 1 -> 3", output)
+		assertOutputContains(t, `project: "work" -> "home"`, output)
+		task := testStore.GetTaskByID(1)
+		if task.Title != "Write report" || task.Description != "Q3 numbers" {
+			t.Errorf("Expected unchanged fields to be kept, got %q / %q", task.Title, task.Description)
+		}
+		if task.Priority != model.High || task.Project != "home" || task.TimeSpent() != 45 {
+			t.Errorf("Expected edited fields, got priority %d, project %q, time %d", task.Priority, task.Project, task.TimeSpent())
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "edit", "1")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "No changes to task 1", output)
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "edit", "1", "--priority", "7")
+		if execErr == nil || !strings.Contains(output, "priority must be between") {
+			t.Errorf("Expected an invalid priority error, got %q", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "do", "1")
+		assertErr(t, output, execErr)
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "edit", "1", "--reopen")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, `status: "done" -> "pending"`, output)
+		if !testStore.GetTaskByID(1).CompletedAt.IsZero() {
+			t.Error("Expected --reopen to reset completion")
+		}
+	})
+}
+
+func TestEditCmd_Editor(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("editor script requires a POSIX shell")
+	}
+	editor := filepath.Join(t.TempDir(), "editor.sh")
+	script := "#!/bin/sh\nsed -e 's/^title: .*/title: Edited title/' -e 's/^priority: .*/priority: 2/' \"$1\" > \"$1.tmp\" && mv \"$1.tmp\" \"$1\"\n"
+	if err := os.WriteFile(editor, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", editor)
+
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, cobraCmd *cobra.Command) {
+		output, execErr := executeCommand(cobraCmd, "add", "Original", "-P", "1")
+		assertErr(t, output, execErr)
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "edit", "1", "--editor")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, `title: "Original" -> "Edited title"`, output)
+		if task := testStore.GetTaskByID(1); task.Title != "Edited title" || task.Priority != model.Medium {
+			t.Errorf("Expected editor changes to be saved, got %q with priority %d", task.Title, task.Priority)
+		}
+	})
+}
+
+func TestUndoRedo(t *testing.T) {
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, _ *cobra.Command) {
+		recorder := store.NewRecorder(testStore, store.NewHistory(filepath.Join(t.TempDir(), "history")))
+		run := func(args ...string) string {
+			t.Helper()
+			output, execErr := executeCommand(cmd.NewRootCmd(recorder), args...)
+			assertErr(t, output, execErr)
+			return output
+		}
+
+		run("add", "Write report")
+		run("add", "Review report")
+		run("edit", "1", "--title", "Write final report")
+		run("do", "2")
+		run("remove", "1")
+
+		output := run("undo")
+		assertOutputContains(t, "Undid operation 5: remove 1 (removed 1)", output)
+		if task := testStore.GetTaskByID(1); task == nil || task.Title != "Write final report" {
+			t.Fatalf("Expected undo to restore task 1 with its edited title, got %+v", task)
+		}
+
+		run("undo", "2")
+		if !testStore.GetTaskByID(2).CompletedAt.IsZero() {
+			t.Error("Expected undo to reopen task 2")
+		}
+		if title := testStore.GetTaskByID(1).Title; title != "Write report" {
+			t.Errorf("Expected undo to revert the edit, got %q", title)
+		}
+
+		output = run("redo")
+		assertOutputContains(t, `Redid operation 3: edit 1 --title="Write final report" (updated 1)`, output)
+		if title := testStore.GetTaskByID(1).Title; title != "Write final report" {
+			t.Errorf("Expected redo to reapply the edit, got %q", title)
+		}
+
+		output = run("log")
+		assertOutputContains(t, "do 2", output)
+		assertOutputContains(t, "undone", output)
+
+		// A new change discards the operations that could have been redone.
+		run("add", "Ship report")
+		output, execErr := executeCommand(cmd.NewRootCmd(recorder), "redo")
+		if execErr == nil || !strings.Contains(output, "nothing to redo") {
+			t.Errorf("Expected nothing to redo after a new change, got %q", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "undo")
+		if execErr == nil || !strings.Contains(output, "not enabled") {
+			t.Errorf("Expected an error without history, got %q", output)
+		}
+	})
+}
+
+func TestFilters(t *testing.T) {
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, cobraCmd *cobra.Command) {
+		for _, args := range [][]string{
+			{"add", "Deploy API", "-p", "work", "-P", "3", "+review"},
+			{"add", "Deploy docs", "-p", "work", "-P", "1"},
+			{"add", "Water plants", "-p", "home", "-P", "2", "+review", "+blocked"},
+			{"add", "Review budget", "-p", "work", "-P", "2"},
+			{"add", "Fix bike", "-p", "home", "-P", "1"},
+		} {
+			output, execErr := executeCommand(cmd.NewRootCmd(testStore), args...)
+			assertErr(t, output, execErr)
+		}
+
+		output, execErr := executeCommand(cmd.NewRootCmd(testStore), "list", "project:work", "priority>=2")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Deploy API", output)
+		assertOutputContains(t, "Review budget", output)
+		if strings.Contains(output, "Deploy docs") || strings.Contains(output, "Water plants") {
+			t.Errorf("Expected only high priority work tasks, got:\n%s", output)
+		}
+
+		// Running the same command again without a filter lists everything.
+		output, execErr = executeCommand(cobraCmd, "list", "project:home")
+		assertErr(t, output, execErr)
+		output, execErr = executeCommand(cobraCmd, "list")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Deploy API", output)
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", `+review and (project:home or title~"api")`)
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Deploy API", output)
+		assertOutputContains(t, "Water plants", output)
+		if strings.Contains(output, "Review budget") {
+			t.Errorf("Expected only tasks tagged review, got:\n%s", output)
+		}
+
+		// "-blocked" is a filter term, not a flag, while flags after the
+		// filter still count.
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", "+review", "-blocked", "-s", "priority", "--columns", "id,title")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "ID  Title\n1   Deploy API\n", output)
+		if strings.Contains(output, "Water plants") {
+			t.Errorf("Expected tasks tagged blocked to be left out, got:\n%s", output)
+		}
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", "+review", "--bogus")
+		if execErr == nil || !strings.Contains(output, "unknown flag: --bogus") {
+			t.Errorf("Expected an unknown flag after the filter to be reported, got %q", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", "priority>=urgent")
+		if execErr == nil || !strings.Contains(output, "invalid filter") {
+			t.Errorf("Expected an invalid filter error, got %q", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "do", "project:home", "+review")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Completed task 3: Water plants", output)
+
+		// Completed tasks are only selected when the filter asks for them.
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", "status:completed")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Water plants", output)
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "edit", "project:work", "--tag", "q3")
+		assertErr(t, output, execErr)
+		for _, id := range []int{1, 2, 4} {
+			if !testStore.GetTaskByID(id).HasTag("q3") {
+				t.Errorf("Expected task %d to be tagged by the bulk edit", id)
+			}
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "remove", "nosuchtask")
+		if execErr == nil || !strings.Contains(output, "invalid task ID") {
+			t.Errorf("Expected plain text not to be used as a bulk filter, got %q", output)
+		}
+	})
+}
+
+func TestFilters_Confirmation(t *testing.T) {
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, cobraCmd *cobra.Command) {
+		for i := 0; i < 5; i++ {
+			output, execErr := executeCommand(cmd.NewRootCmd(testStore), "add", "Chore "+strconv.Itoa(i), "-p", "home")
+			assertErr(t, output, execErr)
+		}
+
+		root := cmd.NewRootCmd(testStore)
+		root.SetIn(strings.NewReader("n\n"))
+		output, execErr := executeCommand(root, "remove", "project:home")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Remove 5 tasks? [y/N]", output)
+		assertOutputContains(t, "Aborted; no tasks were changed.", output)
+		if got := len(testStore.ListAllTasks()); got != 5 {
+			t.Fatalf("Expected declining to keep all tasks, got %d", got)
+		}
+
+		root = cmd.NewRootCmd(testStore)
+		root.SetIn(strings.NewReader("y\n"))
+		output, execErr = executeCommand(root, "remove", "project:home")
+		assertErr(t, output, execErr)
+		if got := len(testStore.ListAllTasks()); got != 0 {
+			t.Errorf("Expected confirming to remove all tasks, got %d left", got)
+		}
+	})
+}
+
+func TestOutputFormats(t *testing.T) {
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, cobraCmd *cobra.Command) {
+		output, execErr := executeCommand(cobraCmd, "add", "Deploy, then verify", "-p", "work", "-P", "3", "--due", "2025-06-06", "+review")
+		assertErr(t, output, execErr)
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "add", "Plan", "--depends", "1")
+		assertErr(t, output, execErr)
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", "-o", "json")
+		assertErr(t, output, execErr)
+		var records []map[string]any
+		if err := json.Unmarshal([]byte(output), &records); err != nil {
+			t.Fatalf("Expected a JSON array, got %v:\n%s", err, output)
+		}
+		if len(records) != 2 || records[0]["title"] != "Deploy, then verify" || records[1]["blocked"] != true {
+			t.Errorf("Unexpected JSON records: %v", records)
+		}
+		if due, _ := records[0]["due"].(string); records[1]["due"] != nil || !strings.HasPrefix(due, "2025-06-06T00:00:00") {
+			t.Errorf("Expected due dates as RFC 3339 or null, got %v and %v", records[0]["due"], records[1]["due"])
+		}
+
+		// Every documented field is present, and nothing undocumented.
+		schemaData, err := os.ReadFile(filepath.Join("..", "docs", "task.schema.json"))
+		if err != nil {
+			t.Fatalf("Failed to read schema: %v", err)
+		}
+		var schema struct {
+			Required   []string       `json:"required"`
+			Properties map[string]any `json:"properties"`
+		}
+		if err := json.Unmarshal(schemaData, &schema); err != nil {
+			t.Fatalf("Failed to parse schema: %v", err)
+		}
+		for _, field := range schema.Required {
+			if _, ok := records[0][field]; !ok {
+				t.Errorf("JSON output is missing documented field %q", field)
+			}
+		}
+		for field := range records[0] {
+			if _, ok := schema.Properties[field]; !ok {
+				t.Errorf("JSON output has undocumented field %q", field)
+			}
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", "-o", "ndjson")
+		assertErr(t, output, execErr)
+		if lines := strings.Split(strings.TrimSpace(output), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[0], `{"id":1,`) {
+			t.Errorf("Expected one JSON object per line, got:\n%s", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", "-o", "csv")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "id,title,description,project,priority", output)
+		assertOutputContains(t, `1,"Deploy, then verify",,work,3,high,pending`, output)
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", "-o", "template={{.ID}}|{{.Title}}|{{join .Tags \",\"}}|{{date .Due}}")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "1|Deploy, then verify|review|2025-06-06\n2|Plan||\n", output)
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "show", "1", "-o", "yaml")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "title: Deploy, then verify", output)
+		assertOutputContains(t, "priority_name: high", output)
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", "project:none", "-o", "json")
+		assertErr(t, output, execErr)
+		if strings.TrimSpace(output) != "[]" {
+			t.Errorf("Expected an empty JSON array, got %q", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", "-o", "xml")
+		if execErr == nil || !strings.Contains(output, "unknown output format") {
+			t.Errorf("Expected an unknown format error, got %q", output)
+		}
+	})
+}
+
+func TestReports(t *testing.T) {
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, cobraCmd *cobra.Command) {
+		for _, args := range [][]string{
+			{"add", "Prepare the quarterly planning presentation", "-p", "work", "-P", "1"},
+			{"add", "Fix login", "-p", "work", "-P", "3", "+review"},
+			{"add", "Buy milk", "-p", "home", "-P", "2"},
+		} {
+			output, execErr := executeCommand(cmd.NewRootCmd(testStore), args...)
+			assertErr(t, output, execErr)
+		}
+
+		cfg := config.Default(t.TempDir())
+		cfg.Reports["standup"] = config.ReportConfig{
+			Description: "Work in progress",
+			Columns:     []string{"id", "priority", "title:12"},
+			Filter:      "project:work",
+			Sort:        "priority",
+			Limit:       5,
+		}
+
+		output, execErr := executeCommand(cmd.NewRootCmd(testStore, cmd.WithConfig(cfg)), "report")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "standup  Work in progress", output)
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore, cmd.WithConfig(cfg)), "report", "standup")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "ID  Priority  Title\n2   High      Fix login\n1   Low       Prepare the…\n", output)
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore, cmd.WithConfig(cfg)), "report", "standup", "+review")
+		assertErr(t, output, execErr)
+		if strings.Contains(output, "Prepare") {
+			t.Errorf("Expected extra filter terms to narrow the report, got:\n%s", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "next")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Age", output)
+		if strings.Index(output, "Fix login") > strings.Index(output, "Buy milk") {
+			t.Errorf("Expected next to show the highest priority first, got:\n%s", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "report", "nope")
+		if execErr == nil || !strings.Contains(output, "unknown report") {
+			t.Errorf("Expected an unknown report error, got %q", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", "--columns", "id,time_spent,bogus")
+		if execErr == nil || !strings.Contains(output, `unknown column "bogus"`) {
+			t.Errorf("Expected an unknown column error, got %q", output)
+		}
+	})
+}
+
+func TestMultiKeySort(t *testing.T) {
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, cobraCmd *cobra.Command) {
+		for _, args := range [][]string{
+			{"add", "Alpha", "-p", "work", "-P", "1"},
+			{"add", "Bravo", "-p", "home", "-P", "3", "--due", "2030-01-02"},
+			{"add", "Charlie", "-p", "work", "-P", "3", "--due", "2030-01-01"},
+			{"add", "Delta", "-p", "home", "-P", "3"},
+			{"add", "Echo", "-p", "alpha", "-P", "3"},
+		} {
+			output, execErr := executeCommand(cmd.NewRootCmd(testStore), args...)
+			assertErr(t, output, execErr)
+		}
+
+		order := func(output string, titles ...string) {
+			t.Helper()
+			last := -1
+			for _, title := range titles {
+				index := strings.Index(output, title)
+				if index < last {
+					t.Errorf("Expected order %v, got:\n%s", titles, output)
+					return
+				}
+				last = index
+			}
+		}
+
+		output, execErr := executeCommand(cmd.NewRootCmd(testStore), "list", "-s", "priority-,due+,project")
+		assertErr(t, output, execErr)
+		order(output, "Charlie", "Bravo", "Echo", "Delta", "Alpha")
+
+		// Tasks without a due date stay last in both directions.
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", "-s", "due-")
+		assertErr(t, output, execErr)
+		order(output, "Bravo", "Charlie", "Alpha", "Delta", "Echo")
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", "-s", "priority")
+		assertErr(t, output, execErr)
+		order(output, "Bravo", "Charlie", "Delta", "Echo", "Alpha")
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", "-s", "bogus")
+		if execErr == nil || !strings.Contains(output, `unknown sort key "bogus"`) {
+			t.Errorf("Expected an unknown sort key error, got %q", output)
+		}
+	})
+}
+
+func TestUrgency(t *testing.T) {
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, cobraCmd *cobra.Command) {
+		for _, args := range [][]string{
+			{"add", "Water plants", "-P", "3"},
+			{"add", "File taxes", "-P", "1", "--due", "yesterday"},
+			{"add", "Read book", "-P", "1", "+next"},
+			{"add", "Blocked work", "-P", "3", "--depends", "2"},
+		} {
+			output, execErr := executeCommand(cmd.NewRootCmd(testStore), args...)
+			assertErr(t, output, execErr)
+		}
+
+		output, execErr := executeCommand(cmd.NewRootCmd(testStore), "next")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Urgency", output)
+		if strings.Contains(output, "Blocked work") {
+			t.Errorf("Expected next to leave out blocked tasks, got:\n%s", output)
+		}
+		if !(strings.Index(output, "Read book") < strings.Index(output, "File taxes") &&
+			strings.Index(output, "File taxes") < strings.Index(output, "Water plants")) {
+			t.Errorf("Expected next to show the most urgent tasks first, got:\n%s", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "next", "-n", "1")
+		assertErr(t, output, execErr)
+		if !strings.Contains(output, "Read book") || strings.Contains(output, "File taxes") {
+			t.Errorf("Expected only the most urgent task, got:\n%s", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", "-s", "urgency+", "--columns", "id,urgency")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "ID  Urgency\n4   1.0\n1   6.0\n", output)
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "show", "2", "--explain-urgency")
+		assertErr(t, output, execErr)
+		for _, want := range []string{"Factor", "priority  low", "due       ", "overdue", "Coefficient"} {
+			assertOutputContains(t, want, output)
+		}
+
+		// Without the tag bonus, the overdue task comes first.
+		cfg := config.Default(t.TempDir())
+		cfg.Urgency.Tag = nil
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore, cmd.WithConfig(cfg)), "next", "-n", "1")
+		assertErr(t, output, execErr)
+		if !strings.Contains(output, "File taxes") {
+			t.Errorf("Expected the configured coefficients to change the order, got:\n%s", output)
+		}
+	})
+}
+
+func TestStatusWorkflow(t *testing.T) {
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, cobraCmd *cobra.Command) {
+		for _, args := range [][]string{
+			{"add", "Ask for quote"},
+			{"add", "Old idea"},
+			{"add", "Build it", "--depends", "2"},
+		} {
+			output, execErr := executeCommand(cmd.NewRootCmd(testStore), args...)
+			assertErr(t, output, execErr)
+		}
+
+		output, execErr := executeCommand(cmd.NewRootCmd(testStore), "wait", "1")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Waiting task 1: Ask for quote", output)
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "cancel", "2")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Cancelled task 2: Old idea", output)
+		if task := testStore.GetTaskByID(2); task.State() != model.StatusCancelled || task.CompletedAt.IsZero() {
+			t.Errorf("Expected task 2 to be cancelled, got %+v", task)
+		}
+
+		// The cancelled dependency no longer blocks task 3, and the waiting
+		// task is not ready.
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", "--ready")
+		assertErr(t, output, execErr)
+		if !strings.Contains(output, "Build it") || strings.Contains(output, "Ask for quote") || strings.Contains(output, "Old idea") {
+			t.Errorf("Expected only task 3 to be ready, got:\n%s", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", "--status", "cancelled,waiting")
+		assertErr(t, output, execErr)
+		if !strings.Contains(output, "Old idea") || !strings.Contains(output, "Ask for quote") || strings.Contains(output, "Build it") {
+			t.Errorf("Expected the cancelled and waiting tasks, got:\n%s", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "do", "2")
+		if execErr == nil || !strings.Contains(output, "task 2 is cancelled") {
+			t.Errorf("Expected an error completing a cancelled task, got %q", output)
+		}
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "wait", "2")
+		if execErr == nil || !strings.Contains(output, "reopen it first") {
+			t.Errorf("Expected an error changing a cancelled task, got %q", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "edit", "2", "--reopen")
+		assertErr(t, output, execErr)
+		if task := testStore.GetTaskByID(2); task.State() != model.StatusPending || !task.CompletedAt.IsZero() {
+			t.Errorf("Expected task 2 to be pending again, got %+v", task)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "start", "1")
+		assertErr(t, output, execErr)
+		if state := testStore.GetTaskByID(1).State(); state != model.StatusInProgress {
+			t.Errorf("Expected starting task 1 to put it in progress, got %q", state)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "edit", "3", "--status", "blocked")
+		assertErr(t, output, execErr)
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", "status:blocked", "-o", "ndjson")
+		assertErr(t, output, execErr)
+		if strings.Count(output, "\n") != 1 || !strings.Contains(output, `"status":"blocked","blocked":true`) {
+			t.Errorf("Expected only task 3 to be blocked, got:\n%s", output)
+		}
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "show", "3")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Blocked: yes", output)
+	})
+}
+
+func TestAnnotations(t *testing.T) {
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, cobraCmd *cobra.Command) {
+		for _, args := range [][]string{
+			{"add", "Order parts"},
+			{"add", "Unrelated"},
+			{"annotate", "1", "Called", "the", "supplier"},
+			{"annotate", "1", "Quote received"},
+		} {
+			output, execErr := executeCommand(cmd.NewRootCmd(testStore), args...)
+			assertErr(t, output, execErr)
+		}
+
+		output, execErr := executeCommand(cmd.NewRootCmd(testStore), "show", "1")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Annotations:", output)
+		first, second := strings.Index(output, "1. "), strings.Index(output, "2. ")
+		if first < 0 || second < first || !strings.Contains(output[first:], "Called the supplier") || !strings.Contains(output[second:], "Quote received") {
+			t.Errorf("Expected annotations listed oldest first, got:\n%s", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", "supplier")
+		assertErr(t, output, execErr)
+		if !strings.Contains(output, "Order parts") || strings.Contains(output, "Unrelated") {
+			t.Errorf("Expected the filter to search annotations, got:\n%s", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "show", "1", "-o", "json")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, `"description": "Quote received"`, output)
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "denotate", "1", "supplier")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Removed annotation from task 1: Called the supplier", output)
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "denotate", "1", "3")
+		if execErr == nil || !strings.Contains(output, "annotation 3 not found") {
+			t.Errorf("Expected an error for a missing annotation, got %q", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "denotate", "1")
+		assertErr(t, output, execErr)
+		if annotations := testStore.GetTaskByID(1).Annotations; len(annotations) != 0 {
+			t.Errorf("Expected no annotations left, got %+v", annotations)
+		}
+	})
+}
+
+func TestTaskReferences(t *testing.T) {
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, cobraCmd *cobra.Command) {
+		for _, title := range []string{"Design", "Build", "Scratch"} {
+			output, execErr := executeCommand(cmd.NewRootCmd(testStore), "add", title)
+			assertErr(t, output, execErr)
+		}
+		design := testStore.GetTaskByID(1)
+		prefix := design.UUID[:8]
+
+		output, execErr := executeCommand(cmd.NewRootCmd(testStore), "show", prefix)
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Title: Design", output)
+		assertOutputContains(t, "UUID: "+design.UUID, output)
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "edit", "2", "--depends", prefix)
+		assertErr(t, output, execErr)
+		if deps := testStore.GetTaskByID(2).DependsOn; len(deps) != 1 || deps[0] != 1 {
+			t.Errorf("Expected task 2 to depend on task 1, got %v", deps)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "do", strings.ToUpper(design.UUID))
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Completed task 1: Design", output)
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "show", "00000000-0000")
+		if execErr == nil || !strings.Contains(output, "no task has a UUID starting with 00000000-0000") {
+			t.Errorf("Expected an error for an unknown UUID, got %q", output)
+		}
+
+		// Removing the newest task does not free its ID.
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "remove", "3")
+		assertErr(t, output, execErr)
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "add", "Replacement")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "(ID: 4)", output)
+	})
+}
+
+func TestTimeTracking(t *testing.T) {
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, cobraCmd *cobra.Command) {
+		for _, args := range [][]string{
+			{"add", "Write report"},
+			{"add", "Review PR"},
+		} {
+			output, execErr := executeCommand(cmd.NewRootCmd(testStore), args...)
+			assertErr(t, output, execErr)
+		}
+
+		output, execErr := executeCommand(cmd.NewRootCmd(testStore), "start", "1")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Started task 1: Write report", output)
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", "--view", "full")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Active: task 1", output)
+		assertOutputContains(t, "▶", output)
+
+		// Starting another task stops the active one.
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "start", "2")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Stopped task 1 after 0m: Write report", output)
+		if testStore.GetTaskByID(1).IsActive() || !testStore.GetTaskByID(2).IsActive() {
+			t.Errorf("Expected only task 2 to be active")
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "list", "status:active", "-o", "ndjson")
+		assertErr(t, output, execErr)
+		if strings.Count(output, "\n") != 1 || !strings.Contains(output, `"active":true`) {
+			t.Errorf("Expected only the active task, got:\n%s", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "stop")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Stopped task 2", output)
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "stop")
+		if execErr == nil || !strings.Contains(output, "no task is started") {
+			t.Errorf("Expected an error when no task is started, got %q", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "track", "1", "45m", "--at", "yesterday")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Tracked 45m on task 1 from", output)
+		entries := testStore.GetTaskByID(1).TimeEntries
+		yesterday := time.Now().AddDate(0, 0, -1)
+		if entry := entries[0]; entry.Start.Day() != yesterday.Day() || entry.Start.Hour() != 9 || entry.End.Sub(entry.Start) != 45*time.Minute {
+			t.Errorf("Expected a 45m entry yesterday from 9:00, got %+v", entries)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "track", "1", "90")
+		assertErr(t, output, execErr)
+		if spent := testStore.GetTaskByID(1).TimeSpent(); spent != 135 {
+			t.Errorf("Expected 135 minutes spent, got %d", spent)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "track", "1", "1h", "--at", "tomorrow")
+		if execErr == nil || !strings.Contains(output, "future") {
+			t.Errorf("Expected an error for time in the future, got %q", output)
+		}
+
+		// Completing an active task stops its clock.
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "start", "2")
+		assertErr(t, output, execErr)
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "do", "2")
+		assertErr(t, output, execErr)
+		if testStore.GetTaskByID(2).IsActive() {
+			t.Errorf("Expected completing task 2 to stop it")
+		}
+	})
+}
+
+func TestTimesheet(t *testing.T) {
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, cobraCmd *cobra.Command) {
+		for _, args := range [][]string{
+			{"add", "Night shift", "-p", "acme"},
+			{"add", "Invoice", "-p", "acme"},
+			{"add", "Chores", "-p", ""},
+			{"add", "Blip", "-p", "acme"},
+		} {
+			output, execErr := executeCommand(cmd.NewRootCmd(testStore), args...)
+			assertErr(t, output, execErr)
+		}
+
+		at := func(day, hour int) time.Time { return time.Date(2025, 6, day, hour, 0, 0, 0, time.Local) }
+		track := func(id int, entries ...model.TimeEntry) {
+			task := testStore.GetTaskByID(id)
+			task.TimeEntries = entries
+			if err := testStore.UpdateTask(task); err != nil {
+				t.Fatalf("Failed to update task %d: %v", id, err)
+			}
+		}
+		// Sunday 22:00 to Monday 02:00 crosses midnight and the ISO week.
+		track(1, model.TimeEntry{Start: at(8, 22), End: at(9, 2)})
+		track(2, model.TimeEntry{Start: at(9, 9), End: at(9, 10)})
+		track(3, model.TimeEntry{Start: at(10, 9), End: at(10, 9).Add(30 * time.Minute)})
+		// Started and stopped again right away.
+		track(4, model.TimeEntry{Start: at(10, 12), End: at(10, 12).Add(10 * time.Second)})
+
+		output, execErr := executeCommand(cmd.NewRootCmd(testStore), "timesheet", "--by", "week")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Week      Time\n2025-W23  2h0m\n2025-W24  3h30m\nTotal     5h30m\n", output)
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "timesheet", "--from", "2025-06-09", "--to", "2025-06-09")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "2025-06-09 Mon  3h0m\nTotal           3h0m\n", output)
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "timesheet", "--by", "project,task")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, `Project          Task           Time
+acme             1 Night shift  4h0m
+acme             2 Invoice      1h0m
+acme subtotal                   5h0m
+(none)           3 Chores       30m
+(none) subtotal                 30m
+Total                           5h30m
+`, output)
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "timesheet", "project:acme", "--by", "day,task", "-o", "csv")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "day,task,minutes,hours\n2025-06-08 Sun,1 Night shift,120,2.00\n2025-06-09 Mon,1 Night shift,120,2.00\n2025-06-09 Mon,2 Invoice,60,1.00\n", output)
+		if strings.Contains(output, "Blip") {
+			t.Errorf("Expected no row for less than a minute, got:\n%s", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "timesheet", "--by", "project", "-o", "json")
+		assertErr(t, output, execErr)
+		var sheet struct {
+			Minutes int64
+			Groups  []struct {
+				Key     string
+				Minutes int64
+				Hours   float64
+			}
+		}
+		if err := json.Unmarshal([]byte(output), &sheet); err != nil {
+			t.Fatalf("Expected JSON output, got %v:\n%s", err, output)
+		}
+		if sheet.Minutes != 330 || len(sheet.Groups) != 2 || sheet.Groups[0].Key != "acme" || sheet.Groups[0].Hours != 5 {
+			t.Errorf("Unexpected timesheet JSON:\n%s", output)
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "timesheet", "--by", "year")
+		if execErr == nil || !strings.Contains(output, `unknown grouping "year"`) {
+			t.Errorf("Expected an unknown grouping error, got %q", output)
+		}
+	})
+}
+
+func TestArchive(t *testing.T) {
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, cobraCmd *cobra.Command) {
+		archive := setupTestStorage(t, func(filename string) (store.TaskRepository, error) { return store.NewJsonStore(filename) })
+		withArchive := cmd.WithArchive(func() (store.TaskRepository, error) { return archive, nil })
+		run := func(args ...string) (string, error) {
+			return executeCommand(cmd.NewRootCmd(testStore, withArchive), args...)
+		}
+
+		for _, args := range [][]string{
+			{"add", "Old report"},
+			{"add", "Cancelled idea"},
+			{"add", "Library"},
+			{"add", "App", "--depends", "3"},
+			{"add", "Open work"},
+			{"do", "1", "3"},
+			{"cancel", "2"},
+		} {
+			output, execErr := run(args...)
+			assertErr(t, output, execErr)
+		}
+
+		output, execErr := run("archive", "--dry-run")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Would archive task 1: Old report", output)
+		assertOutputContains(t, "Kept task 3: open task 4 depends on it", output)
+		if testStore.GetTaskByID(1) == nil {
+			t.Fatal("Expected --dry-run to leave task 1 in the task list")
+		}
+
+		output, execErr = run("archive", "5")
+		if execErr == nil || !strings.Contains(output, "only done and cancelled tasks can be archived") {
+			t.Errorf("Expected archiving an open task to fail, got %q", output)
+		}
+
+		output, execErr = run("archive")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Archived task 1: Old report", output)
+		assertOutputContains(t, "Archived task 2: Cancelled idea", output)
+		if testStore.GetTaskByID(1) != nil || testStore.GetTaskByID(3) == nil {
+			t.Fatalf("Expected task 1 to be archived and task 3 to be kept, got %v", testStore.ListAllTasks())
+		}
+		if archived := archive.GetTaskByID(1); archived == nil || archived.ArchivedAt.IsZero() {
+			t.Fatalf("Expected task 1 in the archive with its archive time, got %+v", archived)
+		}
+
+		output, execErr = run("list", "--archived")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Old report", output)
+		assertOutputContains(t, "Cancelled idea", output)
+		if strings.Contains(output, "Open work") {
+			t.Errorf("Expected --archived to list archived tasks only, got %q", output)
+		}
+
+		// --archived does not stick to the command for later runs.
+		root := cmd.NewRootCmd(testStore, withArchive)
+		output, execErr = executeCommand(root, "list", "--archived")
+		assertErr(t, output, execErr)
+		output, execErr = executeCommand(root, "list", "--archived=false")
+		assertErr(t, output, execErr)
+		if strings.Contains(output, "Library") {
+			t.Errorf("Expected done tasks to be hidden again, got %q", output)
+		}
+
+		output, execErr = run("show", "1")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Title: Old report", output)
+		assertOutputContains(t, "Archived: ", output)
+
+		output, execErr = run("unarchive", "1")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Unarchived task 1: Old report", output)
+		if testStore.GetTaskByID(1) == nil || archive.GetTaskByID(1) != nil {
+			t.Error("Expected task 1 to be back in the task list and out of the archive")
+		}
+
+		output, execErr = executeCommand(cmd.NewRootCmd(testStore), "archive")
+		if execErr == nil || !strings.Contains(output, "no archive is configured") {
+			t.Errorf("Expected archiving without an archive to fail, got %q", output)
+		}
+	})
+}
+
+func TestArchive_Automatic(t *testing.T) {
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, cobraCmd *cobra.Command) {
+		archive := setupTestStorage(t, func(filename string) (store.TaskRepository, error) { return store.NewJsonStore(filename) })
+		cfg := config.Default(t.TempDir())
+		cfg.Archive.AfterDays = 30
+		run := func(args ...string) (string, error) {
+			root := cmd.NewRootCmd(testStore, cmd.WithConfig(cfg), cmd.WithArchive(func() (store.TaskRepository, error) { return archive, nil }))
+			return executeCommand(root, args...)
+		}
+
+		now := time.Now()
+		for _, task := range []*model.Task{
+			{Title: "Long done", Status: model.StatusDone, CompletedAt: now.AddDate(0, 0, -40), TimeEntries: []model.TimeEntry{
+				{Start: now.AddDate(0, 0, -41), End: now.AddDate(0, 0, -41).Add(90 * time.Minute)},
+			}},
+			{Title: "Recently done", Status: model.StatusDone, CompletedAt: now.AddDate(0, 0, -2)},
+			{Title: "Brought back", Status: model.StatusDone, CompletedAt: now.AddDate(0, 0, -40), ArchivedAt: now.AddDate(0, 0, -5)},
+		} {
+			if err := testStore.AddTask(task); err != nil {
+				t.Fatalf("Failed to add task: %v", err)
+			}
+		}
+
+		// Commands that look at closed tasks themselves run first.
+		output, execErr := run("clear", "--completed", "--dry-run")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Would delete task 1: Long done", output)
+
+		output, execErr = run("list")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Archived 1 task(s) closed more than 30 days ago.", output)
+		if testStore.GetTaskByID(1) != nil || archive.GetTaskByID(1) == nil {
+			t.Error("Expected the task done 40 days ago to be archived")
+		}
+		if testStore.GetTaskByID(2) == nil || testStore.GetTaskByID(3) == nil {
+			t.Error("Expected recent and unarchived tasks to stay in the task list")
+		}
+
+		output, execErr = run("timesheet", "--by", "task", "--from", now.AddDate(0, 0, -60).Format("2006-01-02"))
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Long done", output)
+	})
+}
+
+func TestClear(t *testing.T) {
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, cobraCmd *cobra.Command) {
+		cfg := config.Default(t.TempDir())
+		newRoot := func() *cobra.Command { return cmd.NewRootCmd(testStore, cmd.WithConfig(cfg)) }
+		for _, args := range [][]string{
+			{"add", "Shipped", "-p", "work"},
+			{"add", "Dropped", "-p", "home"},
+			{"add", "Chore", "-p", "home"},
+			{"add", "Review", "-p", "work", "--depends", "3", "--parent", "2"},
+			{"do", "1"},
+			{"cancel", "2"},
+		} {
+			output, execErr := executeCommand(newRoot(), args...)
+			assertErr(t, output, execErr)
+		}
+
+		_, execErr := executeCommand(newRoot(), "clear")
+		if execErr == nil {
+			t.Error("Expected clear without a selection to fail")
+		}
+		_, execErr = executeCommand(newRoot(), "clear", "--all", "--completed")
+		if execErr == nil {
+			t.Error("Expected --all to be exclusive")
+		}
+
+		output, execErr := executeCommand(newRoot(), "clear", "--completed", "--dry-run")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Would delete task 1: Shipped", output)
+		assertOutputContains(t, "Would delete task 2: Dropped", output)
+		if got := len(testStore.ListAllTasks()); got != 4 {
+			t.Fatalf("Expected --dry-run to keep all tasks, got %d", got)
+		}
+
+		root := newRoot()
+		root.SetIn(strings.NewReader("n\n"))
+		output, execErr = executeCommand(root, "clear", "--completed")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Delete 2 tasks? [y/N]", output)
+		assertOutputContains(t, "Aborted; no tasks were deleted.", output)
+
+		output, execErr = executeCommand(newRoot(), "clear", "--project", "home", "--yes")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Deleted 2 tasks.", output)
+		assertOutputContains(t, "Removed dependencies on deleted tasks from task 4: Review", output)
+		assertOutputContains(t, "Removed the deleted parent from task 4: Review", output)
+		if testStore.GetTaskByID(2) != nil || testStore.GetTaskByID(3) != nil || testStore.GetTaskByID(1) == nil {
+			t.Errorf("Expected only the home tasks to be deleted, got %v", testStore.ListAllTasks())
+		}
+
+		snapshots, _ := filepath.Glob(filepath.Join(cfg.Backup.Dir, "*"))
+		if len(snapshots) != 1 {
+			t.Fatalf("Expected one snapshot before the purge, got %v", snapshots)
+		}
+		saved, err := store.ReadSnapshot(snapshots[0])
+		if err != nil || len(saved) != 4 {
+			t.Errorf("Expected the snapshot to hold all 4 tasks, got %d (%v)", len(saved), err)
+		}
+
+		output, execErr = executeCommand(newRoot(), "clear", "project:work", "+nothing", "-y")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "No tasks to clear.", output)
+
+		output, execErr = executeCommand(newRoot(), "clear", "--all", "-y")
+		assertErr(t, output, execErr)
+		if got := len(testStore.ListAllTasks()); got != 0 {
+			t.Errorf("Expected --all to delete every task, got %d left", got)
+		}
+	})
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	forEachStore(t, func(t *testing.T, testStore store.TaskRepository, cobraCmd *cobra.Command) {
+		cfg := config.Default(t.TempDir())
+		cfg.Backup.Keep = 3
+		newRoot := func() *cobra.Command { return cmd.NewRootCmd(testStore, cmd.WithConfig(cfg)) }
+		for _, title := range []string{"Write", "Review"} {
+			output, execErr := executeCommand(newRoot(), "add", title)
+			assertErr(t, output, execErr)
+		}
+
+		output, execErr := executeCommand(newRoot(), "backup")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Saved a snapshot of 2 tasks to "+cfg.Backup.Dir, output)
+
+		// Removing takes a snapshot of its own first.
+		output, execErr = executeCommand(newRoot(), "remove", "1")
+		assertErr(t, output, execErr)
+		snapshots, err := store.ListSnapshots(cfg.Backup.Dir)
+		if err != nil || len(snapshots) != 2 {
+			t.Fatalf("Expected a snapshot before remove, got %v (%v)", snapshots, err)
+		}
+
+		output, execErr = executeCommand(newRoot(), "backup", "list")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, snapshots[0].Name, output)
+		assertOutputContains(t, "Tasks", output)
+
+		output, execErr = executeCommand(newRoot(), "add", "Publish")
+		assertErr(t, output, execErr)
+
+		root := newRoot()
+		root.SetIn(strings.NewReader("n\n"))
+		output, execErr = executeCommand(root, "restore", snapshots[1].Name)
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Aborted; no tasks were changed.", output)
+
+		output, execErr = executeCommand(newRoot(), "restore", snapshots[1].Name, "--yes")
+		assertErr(t, output, execErr)
+		assertOutputContains(t, "Restored 2 tasks from ", output)
+		tasks := testStore.ListAllTasks()
+		if len(tasks) != 2 || testStore.GetTaskByID(1) == nil || testStore.GetTaskByID(3) != nil {
+			t.Errorf("Expected tasks 1 and 2 from the first snapshot, got %v", tasks)
+		}
+
+		// Only backup.keep snapshots are kept.
+		if snapshots, _ := store.ListSnapshots(cfg.Backup.Dir); len(snapshots) != 3 {
+			t.Errorf("Expected 3 snapshots to be kept, got %d", len(snapshots))
+		}
+
+		bad := filepath.Join(t.TempDir(), "broken.json")
+		if err := os.WriteFile(bad, []byte(`{"version": 5, "tasks": [{"id": 0}]}`), 0644); err != nil {
+			t.Fatalf("Failed to write snapshot: %v", err)
+		}
+		output, execErr = executeCommand(newRoot(), "restore", bad, "--yes")
+		if execErr == nil || !strings.Contains(output, "invalid snapshot") {
+			t.Errorf("Expected an invalid snapshot to be rejected, got %q", output)
+		}
+		if len(testStore.ListAllTasks()) != 2 {
+			t.Error("Expected a rejected snapshot to leave the tasks alone")
+		}
+	})
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kevin7254/task/dateparse"
+	"github.com/kevin7254/task/model"
+	"github.com/spf13/cobra"
+)
+
+// column is a task field that can be shown in a table.
+type column struct {
+	header string
+	value  func(task *model.Task, env taskEnv) string
+}
+
+// taskEnv holds what columns and sort keys need besides the task itself.
+type taskEnv struct {
+	// lookup resolves related tasks, such as dependencies. It may be nil.
+	lookup model.TaskLookup
+	// urgency scores a task. It may be nil, in which case every task scores 0.
+	urgency func(task *model.Task) float64
+}
+
+// newTaskEnv returns an environment that scores urgency with the configured
+// coefficients at the current time, computing each task's score only once.
+func newTaskEnv(cmd *cobra.Command, lookup model.TaskLookup) taskEnv {
+	coefficients := configOf(cmd).Urgency
+	now := time.Now()
+	scores := make(map[*model.Task]float64)
+	return taskEnv{
+		lookup: lookup,
+		urgency: func(task *model.Task) float64 {
+			score, ok := scores[task]
+			if !ok {
+				score = task.Urgency(coefficients, lookup, now)
+				scores[task] = score
+			}
+			return score
+		},
+	}
+}
+
+// urgencyOf returns the task's urgency score in env.
+func (env taskEnv) urgencyOf(task *model.Task) float64 {
+	if env.urgency == nil {
+		return 0
+	}
+	return env.urgency(task)
+}
+
+// columns lists every column available to --columns and reports.
+var columns = map[string]column{
+	"id":          {"ID", func(t *model.Task, _ taskEnv) string { return strconv.Itoa(t.ID) }},
+	"uuid":        {"UUID", func(t *model.Task, _ taskEnv) string { return t.ShortUUID() }},
+	"status":      {"Status", func(t *model.Task, env taskEnv) string { return getStatusIcon(t, env.lookup) }},
+	"title":       {"Title", func(t *model.Task, _ taskEnv) string { return t.Title }},
+	"description": {"Description", func(t *model.Task, _ taskEnv) string { return t.Description }},
+	"project":     {"Project", func(t *model.Task, _ taskEnv) string { return t.Project }},
+	"priority":    {"Priority", func(t *model.Task, _ taskEnv) string { return getPriorityString(t.Priority) }},
+	"due":         {"Due Date", func(t *model.Task, _ taskEnv) string { return dateparse.Format(t.DueDate) }},
+	"created":     {"Created", func(t *model.Task, _ taskEnv) string { return dateparse.Format(t.CreatedAt) }},
+	"completed":   {"Completed", func(t *model.Task, _ taskEnv) string { return dateparse.Format(t.CompletedAt) }},
+	"age":         {"Age", func(t *model.Task, _ taskEnv) string { return formatAge(t.CreatedAt, time.Now()) }},
+	"time_spent":  {"Time Spent", func(t *model.Task, _ taskEnv) string { return formatMinutes(t.TimeSpent()) }},
+	"tags":        {"Tags", func(t *model.Task, _ taskEnv) string { return formatTags(t.Tags) }},
+	"parent":      {"Parent", func(t *model.Task, _ taskEnv) string { return formatOptionalID(t.ParentID) }},
+	"depends":     {"Depends On", func(t *model.Task, env taskEnv) string { return formatDependencies(t, env.lookup) }},
+	"urgency":     {"Urgency", func(t *model.Task, env taskEnv) string { return formatUrgency(env.urgencyOf(t)) }},
+	"recur": {"Recur", func(t *model.Task, _ taskEnv) string {
+		if t.Recur == nil {
+			return ""
+		}
+		return t.Recur.String()
+	}},
+}
+
+// viewColumns are the columns of the predefined list views.
+var viewColumns = map[string][]string{
+	"basic": {"id", "title"},
+	"full":  {"id", "status", "priority", "due", "project", "tags", "title"},
+}
+
+// columnSpec is a column with an optional maximum width, written "title:40".
+type columnSpec struct {
+	name  string
+	width int
+}
+
+// parseColumns parses column specs such as "id", "title:40".
+func parseColumns(specs []string) ([]columnSpec, error) {
+	parsed := make([]columnSpec, 0, len(specs))
+	for _, spec := range specs {
+		name, widthText, hasWidth := strings.Cut(strings.TrimSpace(spec), ":")
+		name = strings.ToLower(name)
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("unknown column %q (available: %s)", name, strings.Join(columnNames(), ", "))
+		}
+		width := 0
+		if hasWidth {
+			n, err := strconv.Atoi(widthText)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid width for column %q: %s", name, widthText)
+			}
+			width = n
+		}
+		parsed = append(parsed, columnSpec{name: name, width: width})
+	}
+	return parsed, nil
+}
+
+// columnNames returns the names of all available columns, sorted.
+func columnNames() []string {
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// buildColumnData transforms tasks into headers and rows with the given columns.
+func buildColumnData(tasks []*model.Task, specs []columnSpec, env taskEnv) (headers []string, rows [][]string) {
+	headers = make([]string, len(specs))
+	for i, spec := range specs {
+		headers[i] = columns[spec.name].header
+	}
+	rows = make([][]string, len(tasks))
+	for i, task := range tasks {
+		row := make([]string, len(specs))
+		for j, spec := range specs {
+			row[j] = truncate(columns[spec.name].value(task, env), spec.width)
+		}
+		rows[i] = row
+	}
+	return headers, rows
+}
+
+// truncate shortens s to width characters, marking the cut with an ellipsis.
+// A width of zero means no limit.
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if width <= 0 || len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// formatAge renders the time since created in its largest sensible unit.
+func formatAge(created time.Time, now time.Time) string {
+	if created.IsZero() {
+		return ""
+	}
+	age := now.Sub(created)
+	switch {
+	case age < time.Hour:
+		return fmt.Sprintf("%dmin", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	case age < 14*24*time.Hour:
+		return fmt.Sprintf("%dd", int(age.Hours()/24))
+	case age < 365*24*time.Hour:
+		return fmt.Sprintf("%dw", int(age.Hours()/(24*7)))
+	default:
+		return fmt.Sprintf("%dy", int(age.Hours()/(24*365)))
+	}
+}
+
+// formatMinutes renders a number of minutes such as 135 as "2h15m".
+func formatMinutes(minutes int64) string {
+	if minutes == 0 {
+		return ""
+	}
+	return strings.TrimSuffix((time.Duration(minutes) * time.Minute).String(), "0s")
+}
+
+// formatUrgency renders an urgency score with one decimal.
+func formatUrgency(score float64) string {
+	return strconv.FormatFloat(score, 'f', 1, 64)
+}
+
+// formatOptionalID renders a task reference, leaving it empty when unset.
+func formatOptionalID(id int) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.Itoa(id)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/kevin7254/task/config"
+	"github.com/kevin7254/task/store"
+	"github.com/spf13/cobra"
+)
+
+// configKey is the context key under which the root command stores the
+// user's settings.
+type configKey struct{}
+
+// archiveKey is the context key under which the root command stores the
+// function that opens the archive.
+type archiveKey struct{}
+
+// Option configures the root command.
+type Option func(root *cobra.Command)
+
+// WithConfig makes the user's settings available to every command.
+func WithConfig(cfg *config.Config) Option {
+	return func(root *cobra.Command) {
+		root.SetContext(context.WithValue(rootContext(root), configKey{}, cfg))
+	}
+}
+
+// WithArchive makes the archive that closed tasks are moved to available to
+// every command. open is called at most once, when a command first needs the
+// archive.
+func WithArchive(open func() (store.TaskRepository, error)) Option {
+	return func(root *cobra.Command) {
+		root.SetContext(context.WithValue(rootContext(root), archiveKey{}, sync.OnceValues(open)))
+	}
+}
+
+// rootContext returns the context earlier options left on root.
+func rootContext(root *cobra.Command) context.Context {
+	if ctx := root.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
+// configOf returns the settings cmd runs with, falling back to the defaults
+// when the root command was created without WithConfig.
+func configOf(cmd *cobra.Command) *config.Config {
+	if ctx := cmd.Context(); ctx != nil {
+		if cfg, ok := ctx.Value(configKey{}).(*config.Config); ok {
+			return cfg
+		}
+	}
+	return config.Default("")
+}
+
+// archiveOf opens the archive cmd runs with. It fails when the root command
+// was created without WithArchive.
+func archiveOf(cmd *cobra.Command) (store.TaskRepository, error) {
+	if ctx := cmd.Context(); ctx != nil {
+		if open, ok := ctx.Value(archiveKey{}).(func() (store.TaskRepository, error)); ok {
+			archive, err := open()
+			if err != nil {
+				return nil, fmt.Errorf("failed to open the archive: %w", err)
+			}
+			return archive, nil
+		}
+	}
+	return nil, fmt.Errorf("no archive is configured")
+}
+
+// hasArchive reports whether cmd runs with an archive.
+func hasArchive(cmd *cobra.Command) bool {
+	if ctx := cmd.Context(); ctx != nil {
+		_, ok := ctx.Value(archiveKey{}).(func() (store.TaskRepository, error))
+		return ok
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kevin7254/task/model"
+	"github.com/kevin7254/task/store"
+)
+
+// parseIDList resolves task references given as flag values such as "4,5"
+// to task IDs. Empty values are ignored, so "--depends=" clears the list.
+func parseIDList(taskStore store.TaskRepository, values []string) ([]int, error) {
+	var ids []int
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		// Missing IDs are reported by model.ValidateDependencies.
+		id, err := parseTaskRefFlag(taskStore, value)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// formatDependencies lists a task's dependencies, marking closed ones.
+func formatDependencies(task *model.Task, lookup model.TaskLookup) string {
+	parts := make([]string, 0, len(task.DependsOn))
+	for _, id := range task.DependsOn {
+		dep := lookup(id)
+		switch {
+		case dep == nil:
+			parts = append(parts, fmt.Sprintf("%d (missing)", id))
+		case dep.IsClosed():
+			parts = append(parts, fmt.Sprintf("%d (%s)", id, dep.State()))
+		default:
+			parts = append(parts, strconv.Itoa(id))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// removeDependencyOn drops id from the dependencies of every other task and
+// returns the tasks that were changed.
+func removeDependencyOn(taskStore store.TaskRepository, id int) ([]*model.Task, error) {
+	var changed []*model.Task
+	for _, task := range taskStore.ListAllTasks() {
+		if !task.RemoveDependency(id) {
+			continue
+		}
+		if err := taskStore.UpdateTask(task); err != nil {
+			return changed, fmt.Errorf("failed to update task %d: %w", task.ID, err)
+		}
+		changed = append(changed, task)
+	}
+	return changed, nil
+}
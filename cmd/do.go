@@ -2,47 +2,67 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/kevin7254/task/dateparse"
+	"github.com/kevin7254/task/model"
 	"github.com/kevin7254/task/store"
 	"github.com/spf13/cobra"
-	"strconv"
+	"time"
 )
 
 func NewDoCmd(store store.TaskRepository) *cobra.Command {
-	var timeSpent int
+	var (
+		timeSpent int
+		cascade   bool
+		yes       bool
+	)
 	cobraCmd := &cobra.Command{
-		Use:   "do ID [ID...]",
+		Use:   "do ID [ID...] | FILTER",
 		Short: "Mark task(s) as completed",
-		Long: `Mark one or more tasks as completed by their IDs.
+		Long: `Mark one or more tasks as completed by their IDs, or every pending task
+matching a filter (see "task list --help" for the filter syntax).
 
 Examples:
   task do 1           # Mark task with ID 1 as completed
   task do 1 2 3       # Mark multiple tasks as completed
-  task do 1 --time 30 # Mark task as completed and log 30 minutes spent`,
+  task do 1 --time 30 # Mark task as completed and log 30 minutes spent
+  task do 1 --cascade # Also complete all open subtasks of task 1
+  task do project:work +review  # Complete all pending work tasks tagged review`,
 		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			var ids []int
-			for _, arg := range args {
-				id, err := strconv.Atoi(arg)
-				if err != nil {
-					return fmt.Errorf("invalid task ID: %s", arg)
-				}
-				ids = append(ids, id)
+			selected, selectErr := selectTasks(cmd, store, args, yes, "Complete")
+			if selectErr != nil {
+				return selectErr
 			}
 
-			for _, id := range ids {
+			for _, selectedTask := range selected {
+				// Reload the task, as completing an earlier one may have changed it.
+				id := selectedTask.ID
 				task := store.GetTaskByID(id)
 				if task == nil {
 					return fmt.Errorf("task with ID %d not found", id)
 				}
+				if task.State() == model.StatusCancelled {
+					return fmt.Errorf("task %d is cancelled; reopen it first", id)
+				}
 
-				if timeSpent > 0 {
-					task.AddTimeSpent(int64(timeSpent))
+				openSubtasks := openDescendantsOf(store.ListAllTasks(), id)
+				if len(openSubtasks) > 0 && !cascade {
+					return fmt.Errorf("task %d has %d open subtask(s); complete them first or use --cascade", id, len(openSubtasks))
+				}
+				for _, subtask := range openSubtasks {
+					if err := completeTask(cmd, store, subtask); err != nil {
+						return err
+					}
+					cmd.Printf("Completed subtask %d: %s\n", subtask.ID, subtask.Title)
 				}
 
-				task.Complete()
+				if timeSpent > 0 {
+					spent := time.Duration(timeSpent) * time.Minute
+					task.Track(time.Now().Add(-spent), spent)
+				}
 
-				if err := store.UpdateTask(task); err != nil {
-					return fmt.Errorf("failed to update task %d: %w", id, err)
+				if err := completeTask(cmd, store, task); err != nil {
+					return err
 				}
 
 				cmd.Printf("Completed task %d: %s\n", id, task.Title)
@@ -51,5 +71,29 @@ Examples:
 		},
 	}
 	cobraCmd.Flags().IntVarP(&timeSpent, "time", "t", 0, "Time spent on the task in minutes")
-	return cobraCmd
+	cobraCmd.Flags().BoolVar(&cascade, "cascade", false, "Also complete all open subtasks")
+	cobraCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Don't ask for confirmation when a filter matches many tasks")
+	return acceptFilterArgs(store, cobraCmd)
+}
+
+// completeTask marks task as completed and, if it recurs, adds its next occurrence.
+func completeTask(cmd *cobra.Command, taskStore store.TaskRepository, task *model.Task) error {
+	next := task.NextOccurrence()
+	if next != nil {
+		if err := taskStore.AddTask(next); err != nil {
+			return fmt.Errorf("failed to add next occurrence of task %d: %w", task.ID, err)
+		}
+		// Only the newest instance carries the rule, so stopping it stops the series.
+		task.Recur = nil
+	}
+
+	task.Complete()
+	if err := taskStore.UpdateTask(task); err != nil {
+		return fmt.Errorf("failed to update task %d: %w", task.ID, err)
+	}
+
+	if next != nil {
+		cmd.Printf("Next occurrence of task %d is task %d, due %s\n", task.ID, next.ID, dateparse.Format(next.DueDate))
+	}
+	return nil
 }
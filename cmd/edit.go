@@ -2,46 +2,168 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/kevin7254/task/model"
 	"github.com/kevin7254/task/store"
 	"github.com/spf13/cobra"
-	"strconv"
 )
 
 func NewEditCmd(store store.TaskRepository) *cobra.Command {
-	var title string
+	var (
+		edits    editableTask
+		tags     []string
+		untags   []string
+		depends  []string
+		parent   string
+		reopen   bool
+		inEditor bool
+		yes      bool
+	)
 	cobraCmd := &cobra.Command{
-		Use:   "edit [ID]",
+		Use:   "edit ID [ID...] | FILTER",
 		Short: "Edit task",
-		Long: `Edit a task. Right now only title (--title or -t) is supported.
+		Long: `Edit any field of a task. Only the flags that are given are changed.
+With --editor the task is opened as YAML in $EDITOR instead; the result is
+validated when the editor exits and the changes are reported.
+
+Instead of IDs, a filter applies the flags to every pending task that
+matches it (see "task list --help" for the syntax).
 
 Examples:
-  task edit 1 --title "New title"           # Edit title of task with ID 1`,
+  task edit 1 --title "New title"           # Edit title of task with ID 1
+  task edit 1 --project home --priority 3   # Move to another project, raise priority
+  task edit 1 --due fri                     # Due this coming Friday
+  task edit 1 --due none                    # Remove the due date
+  task edit 1 --time 45                     # Set time spent to 45 minutes
+  task edit 1 --reopen                      # Mark a done or cancelled task as pending again
+  task edit 1 --status blocked              # Move the task to another status
+  task edit 1 --tag review --untag blocked  # Add and remove tags
+  task edit 7 --depends 4,5                 # Task 7 waits for tasks 4 and 5
+  task edit 7 --depends=                    # Remove all dependencies
+  task edit 3 --parent 1                    # Make task 3 a subtask of task 1
+  task edit 1 --editor                      # Edit all fields in $EDITOR
+  task edit project:old --project archive   # Move every pending 'old' task`,
 		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if len(args) != 1 {
-				return fmt.Errorf("exactly one task ID must be provided")
+			tasks, selectErr := selectTasks(cmd, store, args, yes, "Edit")
+			if selectErr != nil {
+				return selectErr
 			}
-
-			id, atoiErr := strconv.Atoi(args[0])
-			if atoiErr != nil {
-				return fmt.Errorf("invalid task ID: %s", args[0])
+			if inEditor && len(tasks) > 1 {
+				return fmt.Errorf("--editor edits a single task, but %d tasks were selected", len(tasks))
 			}
 
-			task := store.GetTaskByID(id)
-			if task == nil {
-				return fmt.Errorf("task with ID %d not found", id)
+			var dependsOn []int
+			if cmd.Flags().Changed("depends") {
+				parsed, err := parseIDList(store, depends)
+				if err != nil {
+					return err
+				}
+				dependsOn = parsed
+			}
+			var parentID int
+			if cmd.Flags().Changed("parent") {
+				parsed, err := parseTaskRefFlag(store, parent)
+				if err != nil {
+					return err
+				}
+				parentID = parsed
 			}
 
-			task.Title = title
+			for _, task := range tasks {
+				id := task.ID
+				wasDone := task.State() == model.StatusDone
+				before := toEditable(task)
+				after := toEditable(task)
+				if inEditor {
+					edited, err := editInEditor(cmd, id, before)
+					if err != nil {
+						return err
+					}
+					after = edited
+				} else {
+					flags := cmd.Flags()
+					if flags.Changed("title") {
+						after.Title = edits.Title
+					}
+					if flags.Changed("description") {
+						after.Description = edits.Description
+					}
+					if flags.Changed("project") {
+						after.Project = edits.Project
+					}
+					if flags.Changed("priority") {
+						after.Priority = edits.Priority
+					}
+					if flags.Changed("due") {
+						after.Due = edits.Due
+					}
+					if flags.Changed("time") {
+						after.TimeSpent = edits.TimeSpent
+					}
+					if flags.Changed("status") {
+						after.Status = edits.Status
+					}
+					if reopen {
+						after.Status = string(model.StatusPending)
+					}
+					if flags.Changed("parent") {
+						after.Parent = parentID
+					}
+					if flags.Changed("recur") {
+						after.Recur = edits.Recur
+					}
+					if flags.Changed("depends") {
+						after.DependsOn = dependsOn
+					}
+					tagged := &model.Task{Tags: after.Tags}
+					tagged.AddTags(tags...)
+					tagged.RemoveTags(untags...)
+					after.Tags = tagged.Tags
+				}
 
-			if err := store.UpdateTask(task); err != nil {
-				return fmt.Errorf("failed to update task %d: %w", id, err)
-			}
+				if err := applyEdits(task, before, after, store.GetTaskByID); err != nil {
+					return fmt.Errorf("task %d: %w", id, err)
+				}
+				// Report the normalized result rather than the raw input, so
+				// "--due fri" shows the resolved date.
+				changes := diffEditable(before, toEditable(task))
+				if len(changes) == 0 {
+					cmd.Printf("No changes to task %d\n", id)
+					continue
+				}
+
+				if !wasDone && task.State() == model.StatusDone {
+					// Completing a task by editing it goes on to the next
+					// occurrence, like "task do".
+					if err := completeTask(cmd, store, task); err != nil {
+						return err
+					}
+				} else if err := store.UpdateTask(task); err != nil {
+					return fmt.Errorf("failed to update task %d: %w", id, err)
+				}
 
-			cmd.Printf("Updated task with ID %d to: %s\n", id, task.Title)
+				cmd.Printf("Updated task with ID %d to: %s\n", id, task.Title)
+				for _, change := range changes {
+					cmd.Printf("  %s\n", change)
+				}
+			}
 			return nil
 		},
 	}
-	cobraCmd.Flags().StringVarP(&title, "title", "t", "", "Edit task title")
-	return cobraCmd
+	cobraCmd.Flags().StringVarP(&edits.Title, "title", "t", "", "Edit task title")
+	cobraCmd.Flags().StringVarP(&edits.Description, "description", "d", "", "Edit task description")
+	cobraCmd.Flags().StringVarP(&edits.Project, "project", "p", "", "Move the task to another project")
+	cobraCmd.Flags().IntVarP(&edits.Priority, "priority", "P", 0, "Task priority (1=Low, 2=Medium, 3=High)")
+	cobraCmd.Flags().StringVar(&edits.Due, "due", "", "Due date, e.g. 2025-06-03, tomorrow, fri, \"in 3 days\", or none")
+	cobraCmd.Flags().Int64Var(&edits.TimeSpent, "time", 0, "Set the time spent on the task in minutes")
+	cobraCmd.Flags().StringVar(&edits.Status, "status", "", "Status: pending, in-progress, waiting, blocked, done or cancelled")
+	cobraCmd.Flags().BoolVar(&reopen, "reopen", false, "Mark the task as pending again")
+	cobraCmd.Flags().StringSliceVar(&tags, "tag", nil, "Tag to add (repeatable)")
+	cobraCmd.Flags().StringSliceVar(&untags, "untag", nil, "Tag to remove (repeatable)")
+	cobraCmd.Flags().StringVar(&parent, "parent", "", "ID or UUID prefix of the parent task (0 to make it a top-level task)")
+	cobraCmd.Flags().StringSliceVar(&depends, "depends", nil, "Replace the IDs or UUID prefixes of tasks that must be completed first")
+	cobraCmd.Flags().StringVar(&edits.Recur, "recur", "", "Recurrence rule, or none to stop recurring")
+	cobraCmd.Flags().BoolVarP(&inEditor, "editor", "e", false, "Edit the task as YAML in $EDITOR")
+	cobraCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Don't ask for confirmation when a filter matches many tasks")
+	return acceptFilterArgs(store, cobraCmd)
 }
@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kevin7254/task/dateparse"
+	"github.com/kevin7254/task/model"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// editableTask is the user-facing, editable form of a model.Task. Dates and
+// recurrence rules are kept as the strings accepted on the command line.
+type editableTask struct {
+	Title       string   `yaml:"title"`
+	Description string   `yaml:"description"`
+	Project     string   `yaml:"project"`
+	Priority    int      `yaml:"priority"`
+	Due         string   `yaml:"due"`
+	TimeSpent   int64    `yaml:"time_spent"`
+	Status      string   `yaml:"status"`
+	Tags        []string `yaml:"tags"`
+	Parent      int      `yaml:"parent"`
+	DependsOn   []int    `yaml:"depends_on"`
+	Recur       string   `yaml:"recur"`
+}
+
+// editorHeader explains the file opened by "task edit --editor".
+const editorHeader = `# Editing task %d. Save and quit to apply, or leave unchanged to cancel.
+# priority: 1=Low, 2=Medium, 3=High. due: e.g. 2025-06-03, tomorrow, fri, or empty for none.
+# recur: e.g. daily, weekly:mon,fri, monthly:15, or empty. parent: 0 for none.
+# status: pending, in-progress, waiting, blocked, done or cancelled.
+`
+
+// toEditable converts a task into its editable form.
+func toEditable(task *model.Task) editableTask {
+	e := editableTask{
+		Title:       task.Title,
+		Description: task.Description,
+		Project:     task.Project,
+		Priority:    int(task.Priority),
+		Due:         dateparse.Format(task.DueDate),
+		TimeSpent:   task.TimeSpent(),
+		Status:      string(task.State()),
+		Tags:        slices.Clone(task.Tags),
+		Parent:      task.ParentID,
+		DependsOn:   slices.Clone(task.DependsOn),
+	}
+	if task.Recur != nil {
+		e.Recur = task.Recur.String()
+	}
+	return e
+}
+
+// applyEdits validates the fields that differ between before and after and
+// writes them to task. Nothing is changed if any field is invalid.
+func applyEdits(task *model.Task, before, after editableTask, lookup model.TaskLookup) error {
+	updated := *task
+	// Stopping the clock when closing the task must not touch the original.
+	updated.TimeEntries = slices.Clone(task.TimeEntries)
+
+	if after.Title != before.Title {
+		if strings.TrimSpace(after.Title) == "" {
+			return fmt.Errorf("task title cannot be empty")
+		}
+		updated.Title = after.Title
+	}
+	updated.Description = after.Description
+	updated.Project = after.Project
+
+	if after.Priority != before.Priority {
+		if after.Priority < 1 || after.Priority > 3 {
+			return fmt.Errorf("priority must be between 1 (Low) and 3 (High)")
+		}
+		updated.Priority = model.Priority(after.Priority)
+	}
+
+	// Only reparse the due date when it changed, so an unchanged date keeps
+	// its exact stored value.
+	if after.Due != before.Due {
+		due, err := parseDateFlag(orNone(after.Due))
+		if err != nil {
+			return err
+		}
+		updated.DueDate = due
+	}
+
+	if after.TimeSpent < 0 {
+		return fmt.Errorf("time spent cannot be negative")
+	}
+	if after.TimeSpent != before.TimeSpent {
+		updated.SetTimeSpent(after.TimeSpent, time.Now())
+	}
+
+	if after.Status != before.Status {
+		status, err := model.ParseStatus(after.Status)
+		if err != nil {
+			return err
+		}
+		if status != updated.State() {
+			if err := updated.SetStatus(status, time.Now()); err != nil {
+				return err
+			}
+		}
+	}
+
+	updated.Tags = nil
+	updated.AddTags(after.Tags...)
+
+	if after.Parent != before.Parent {
+		if err := validateParent(task.ID, after.Parent, lookup); err != nil {
+			return err
+		}
+		updated.ParentID = after.Parent
+	}
+
+	if !slices.Equal(after.DependsOn, before.DependsOn) {
+		updated.DependsOn = slices.Clone(after.DependsOn)
+		if len(updated.DependsOn) == 0 {
+			updated.DependsOn = nil
+		}
+		if err := model.ValidateDependencies(&updated, lookup); err != nil {
+			return err
+		}
+	}
+
+	if after.Recur != before.Recur {
+		updated.Recur = nil
+		if !dateparse.IsNone(orNone(after.Recur)) {
+			rule, err := model.ParseRecurrence(after.Recur)
+			if err != nil {
+				return err
+			}
+			updated.Recur = rule
+		}
+	}
+
+	*task = updated
+	return nil
+}
+
+// orNone maps an empty value to dateparse.None.
+func orNone(value string) string {
+	if strings.TrimSpace(value) == "" {
+		return dateparse.None
+	}
+	return value
+}
+
+// validateParent checks that parentID exists and is not the task itself or
+// one of its own subtasks.
+func validateParent(taskID int, parentID int, lookup model.TaskLookup) error {
+	if parentID == 0 {
+		return nil
+	}
+	for id, seen := parentID, map[int]bool{}; id != 0 && !seen[id]; {
+		if id == taskID {
+			return fmt.Errorf("task %d cannot be its own parent or ancestor", taskID)
+		}
+		seen[id] = true
+		parent := lookup(id)
+		if parent == nil {
+			return fmt.Errorf("parent task with ID %d not found", id)
+		}
+		id = parent.ParentID
+	}
+	return nil
+}
+
+// diffEditable describes every field that differs between before and after.
+func diffEditable(before, after editableTask) []string {
+	var changes []string
+	bv, av := reflect.ValueOf(before), reflect.ValueOf(after)
+	for i := 0; i < bv.NumField(); i++ {
+		b, a := bv.Field(i).Interface(), av.Field(i).Interface()
+		if reflect.DeepEqual(b, a) {
+			continue
+		}
+		name := strings.Split(bv.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		changes = append(changes, fmt.Sprintf("%s: %s -> %s", name, formatEditValue(b), formatEditValue(a)))
+	}
+	return changes
+}
+
+func formatEditValue(v any) string {
+	switch value := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", value)
+	case []string:
+		return "[" + strings.Join(value, ", ") + "]"
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// editInEditor opens the task in the user's editor as YAML and returns the
+// edited form. The editor is taken from $VISUAL or $EDITOR, falling back to vi.
+func editInEditor(cmd *cobra.Command, id int, current editableTask) (editableTask, error) {
+	content, marshErr := yaml.Marshal(current)
+	if marshErr != nil {
+		return current, fmt.Errorf("failed to encode task: %w", marshErr)
+	}
+
+	tmp, osErr := os.CreateTemp("", fmt.Sprintf("task-%d-*.yaml", id))
+	if osErr != nil {
+		return current, fmt.Errorf("failed to create temp file: %w", osErr)
+	}
+	defer os.Remove(tmp.Name())
+
+	original := append([]byte(fmt.Sprintf(editorHeader, id)), content...)
+	if _, err := tmp.Write(original); err != nil {
+		_ = tmp.Close()
+		return current, fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return current, fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	editorArgs := append(strings.Fields(editor), tmp.Name())
+	editorCmd := exec.Command(editorArgs[0], editorArgs[1:]...)
+	editorCmd.Stdin = cmd.InOrStdin()
+	editorCmd.Stdout = cmd.OutOrStdout()
+	editorCmd.Stderr = cmd.ErrOrStderr()
+	if err := editorCmd.Run(); err != nil {
+		return current, fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+
+	edited, readErr := os.ReadFile(tmp.Name())
+	if readErr != nil {
+		return current, fmt.Errorf("failed to read edited task: %w", readErr)
+	}
+	if bytes.Equal(edited, original) {
+		return current, nil
+	}
+
+	var result editableTask
+	decoder := yaml.NewDecoder(bytes.NewReader(edited))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&result); err != nil {
+		return current, fmt.Errorf("invalid task file: %w", err)
+	}
+	return result, nil
+}
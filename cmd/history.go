@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kevin7254/task/store"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// errNoHistory is returned by the history commands when the store is not
+// wrapped in a store.Recorder.
+var errNoHistory = errors.New("undo history is not enabled for this task store")
+
+// recorderOf returns the recorder that keeps the history of taskStore.
+func recorderOf(taskStore store.TaskRepository) (*store.Recorder, error) {
+	recorder, ok := taskStore.(*store.Recorder)
+	if !ok {
+		return nil, errNoHistory
+	}
+	return recorder, nil
+}
+
+// beginOperation starts a new history operation for cmd if the store records
+// history, so that everything cmd changes can be undone in one step.
+func beginOperation(taskStore store.TaskRepository, cmd *cobra.Command, args []string) {
+	if recorder, ok := taskStore.(*store.Recorder); ok {
+		recorder.Begin(commandLine(cmd, args))
+	}
+}
+
+// commandLine describes how cmd was invoked, without the root command name.
+func commandLine(cmd *cobra.Command, args []string) string {
+	parts := []string{strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")}
+	parts = append(parts, args...)
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		value := flag.Value.String()
+		if strings.HasSuffix(flag.Value.Type(), "Slice") {
+			value = strings.Trim(value, "[]")
+		}
+		if flag.Value.Type() == "bool" && value == "true" {
+			parts = append(parts, "--"+flag.Name)
+			return
+		}
+		parts = append(parts, fmt.Sprintf("--%s=%s", flag.Name, strconv.Quote(value)))
+	})
+	return strings.Join(parts, " ")
+}
+
+// parseCount parses the optional operation count argument of undo and redo.
+func parseCount(args []string) (int, error) {
+	if len(args) == 0 {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid number of operations: %s", args[0])
+	}
+	return n, nil
+}
+
+// NewUndoCmd creates the 'undo' command, which reverts recorded operations.
+func NewUndoCmd(taskStore store.TaskRepository) *cobra.Command {
+	return &cobra.Command{
+		Use:   "undo [N]",
+		Short: "Undo the last change(s)",
+		Long: `Undo the last N operations (default 1). Every command that changes tasks
+is recorded as one operation, so undoing "task do --cascade 1" reopens all
+the tasks it completed.
+
+Examples:
+  task undo      # Undo the last operation
+  task undo 3    # Undo the last three operations`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			n, countErr := parseCount(args)
+			if countErr != nil {
+				return countErr
+			}
+			recorder, historyErr := recorderOf(taskStore)
+			if historyErr != nil {
+				return historyErr
+			}
+
+			undone, undoErr := recorder.Undo(n)
+			for _, op := range undone {
+				cmd.Printf("Undid operation %d: %s (%s)\n", op.ID, op.Command, summarizeChanges(op))
+			}
+			if undoErr != nil {
+				return fmt.Errorf("failed to undo: %w", undoErr)
+			}
+			return nil
+		},
+	}
+}
+
+// NewRedoCmd creates the 'redo' command, which reapplies undone operations.
+func NewRedoCmd(taskStore store.TaskRepository) *cobra.Command {
+	return &cobra.Command{
+		Use:   "redo [N]",
+		Short: "Redo the last undone change(s)",
+		Long: `Redo the next N undone operations (default 1). Operations can only be
+redone until another change is made.
+
+Examples:
+  task redo      # Redo the last undone operation
+  task redo 2    # Redo two operations`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			n, countErr := parseCount(args)
+			if countErr != nil {
+				return countErr
+			}
+			recorder, historyErr := recorderOf(taskStore)
+			if historyErr != nil {
+				return historyErr
+			}
+
+			redone, redoErr := recorder.Redo(n)
+			for _, op := range redone {
+				cmd.Printf("Redid operation %d: %s (%s)\n", op.ID, op.Command, summarizeChanges(op))
+			}
+			if redoErr != nil {
+				return fmt.Errorf("failed to redo: %w", redoErr)
+			}
+			return nil
+		},
+	}
+}
+
+// NewLogCmd creates the 'log' command, which shows the operation history.
+func NewLogCmd(taskStore store.TaskRepository) *cobra.Command {
+	var limit int
+	cobraCmd := &cobra.Command{
+		Use:   "log",
+		Short: "Show the history of changes",
+		Long: `Show the recorded operations, newest first. Undone operations are marked
+and can be redone with "task redo".
+
+Examples:
+  task log           # Show the last 10 operations
+  task log -n 0      # Show the whole history`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			recorder, historyErr := recorderOf(taskStore)
+			if historyErr != nil {
+				return historyErr
+			}
+			ops, opsErr := recorder.History().Operations()
+			if opsErr != nil {
+				return opsErr
+			}
+			if len(ops) == 0 {
+				cmd.Println("No history recorded yet.")
+				return nil
+			}
+
+			var rows [][]string
+			for i := len(ops) - 1; i >= 0 && (limit <= 0 || len(rows) < limit); i-- {
+				op := ops[i]
+				state := ""
+				if op.Undone {
+					state = "undone"
+				}
+				rows = append(rows, []string{
+					strconv.Itoa(op.ID),
+					op.Time.Local().Format("2006-01-02 15:04:05"),
+					op.Command,
+					summarizeChanges(op),
+					state,
+				})
+			}
+			dm := NewDisplayManager(cmd.OutOrStdout())
+			return dm.renderTable([]string{"ID", "Time", "Command", "Changes", "State"}, rows)
+		},
+	}
+	cobraCmd.Flags().IntVarP(&limit, "limit", "n", 10, "Number of operations to show (0 for all)")
+	return cobraCmd
+}
+
+// summarizeChanges lists the tasks added, updated and removed by op.
+func summarizeChanges(op store.Operation) string {
+	var added, updated, removed []string
+	for _, change := range op.Changes {
+		id := strconv.Itoa(change.ID)
+		switch {
+		case change.Before == nil:
+			added = append(added, id)
+		case change.After == nil:
+			removed = append(removed, id)
+		default:
+			updated = append(updated, id)
+		}
+	}
+
+	var parts []string
+	for _, group := range []struct {
+		verb string
+		ids  []string
+	}{{"added", added}, {"updated", updated}, {"removed", removed}} {
+		if len(group.ids) > 0 {
+			parts = append(parts, group.verb+" "+strings.Join(group.ids, ", "))
+		}
+	}
+	if len(parts) == 0 {
+		return "no changes"
+	}
+	return strings.Join(parts, "; ")
+}
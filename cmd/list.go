@@ -3,73 +3,195 @@ package cmd
 import (
 	"fmt"
 	"io"
-	"sort"
+	"slices"
 	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/kevin7254/task/model"
+	"github.com/kevin7254/task/query"
 	"github.com/kevin7254/task/store"
 	"github.com/spf13/cobra"
 )
 
 // listOptions holds all the flag-related values for the list command.
 type listOptions struct {
+	filter        query.Expr
 	projectFilter string
+	tags          []string
+	excludedTags  []string
 	showCompleted bool
+	archived      bool
+	readyOnly     bool
+	statuses      []model.Status
 	sortBy        string
 	view          string
+	columns       []string
+	output        string
 }
 
 // NewListCmd creates and configures the 'list' command.
 func NewListCmd(taskStore store.TaskRepository) *cobra.Command {
 	opts := &listOptions{}
+	var statusNames []string
 
 	listCmd := &cobra.Command{
-		Use:   "list",
+		Use:   "list [FILTER]",
 		Short: "List tasks",
 		Long: `List tasks with optional filtering and sorting.
 
+A filter is a list of terms that must all match. Terms can be combined with
+"and", "or" and "not", and grouped with parentheses:
+
+  project:work          field equals value (case-insensitive)
+  priority>=2           compare with = != < <= > >=; also pri:high
+  title~deploy          field contains text (!~ does not contain)
+  due.before:fri        modifiers: is, isnt, before, after, has, hasnt,
+                        startswith, endswith
+  due:none              field is not set
+  +review -blocked      task has / does not have the tag
+  status:waiting        pending, in-progress, waiting, blocked, done,
+                        cancelled, open, overdue, ready or active
+  42                    task ID
+  deploy                title, description or an annotation contains the word
+
+Fields: id, title, description, project, priority, due, created, completed,
+tag, parent, depends, recur, status, annotation. Quote the filter to protect spaces,
+parentheses and quotes from the shell. Flags may follow the filter; other
+words starting with "-", such as -blocked, are filter terms. Done and
+cancelled tasks are hidden unless -c or --status is given or the filter
+mentions status or completed.
+
 Examples:
   task list              # List all incomplete tasks (basic view)
   task list --view full  # List all incomplete tasks (full view)
+  task list --view tree  # List tasks nested under their parent tasks
   task list -c           # List all tasks including completed ones
+  task list --archived   # List archived tasks
+  task list --ready      # List pending tasks that are not blocked
+  task list --status waiting,blocked  # List tasks that are held up
   task list -p work      # List tasks in the 'work' project
-  task list -s priority  # Sort tasks by priority`,
+  task list --tag review --no-tag blocked  # Tagged review but not blocked
+  task list -s priority  # Sort tasks by priority
+  task list -s priority-,due+,project  # Sort by several keys
+  task list -s urgency --columns id,urgency,title  # Most urgent first
+  task list --columns id,age,time_spent,title:40  # Choose the columns
+  task list -o json      # Print tasks as JSON for scripts
+  task list -o template='{{.ID}} {{.Title}}'
+  task list +review -blocked -s priority  # Tagged review but not blocked
+  task list 'project:work (priority>=2 or +urgent) due.before:fri'`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			// The command may run more than once, so nothing is left over
+			// from an earlier run.
+			opts.filter = nil
+			opts.statuses = nil
+			if len(args) > 0 {
+				expr, err := parseQuery(args)
+				if err != nil {
+					return err
+				}
+				opts.filter = expr
+			}
+			for _, name := range statusNames {
+				status, err := model.ParseStatus(name)
+				if err != nil {
+					return err
+				}
+				opts.statuses = append(opts.statuses, status)
+			}
+			format, formatErr := parseOutputFormat(opts.output)
+			if formatErr != nil {
+				return formatErr
+			}
+			sortKeys, sortErr := parseSortKeys(opts.sortBy)
+			if sortErr != nil {
+				return sortErr
+			}
+			specs, columnsErr := parseColumns(opts.columns)
+			if columnsErr != nil {
+				return columnsErr
+			}
+
 			allTasks := taskStore.ListAllTasks()
+			lookup := taskStore.GetTaskByID
+			if opts.archived {
+				archive, err := archiveOf(cmd)
+				if err != nil {
+					return err
+				}
+				allTasks = archive.ListAllTasks()
+				lookup = lookupWithArchive(taskStore, archive)
+			}
+			env := newTaskEnv(cmd, lookup)
+
+			// Scripts get an empty result rather than a message.
+			if !format.isTable() {
+				filteredTasks := filterTasks(allTasks, opts, lookup)
+				sortTasks(filteredTasks, sortKeys, env)
+				return format.writeTasks(cmd.OutOrStdout(), filteredTasks, lookup, false)
+			}
 
 			if len(allTasks) == 0 {
 				cmd.Println("No tasks found.")
 				return nil
 			}
 
-			filteredTasks := filterTasks(allTasks, opts)
+			filteredTasks := filterTasks(allTasks, opts, lookup)
 			if len(filteredTasks) == 0 {
 				cmd.Println("No tasks match the filter criteria.")
 				return nil
 			}
 
-			sortTasks(filteredTasks, opts)
+			sortTasks(filteredTasks, sortKeys, env)
+
+			for _, active := range activeTasks(allTasks) {
+				cmd.Printf("Active: task %d, %s: %s\n", active.ID, formatDuration(time.Since(active.ActiveSince())), active.Title)
+			}
 
 			dm := NewDisplayManager(cmd.OutOrStdout())
+			dm.env = env
+			if len(specs) > 0 && opts.view != "tree" {
+				return dm.RenderColumns(filteredTasks, specs)
+			}
 			return dm.RenderTasks(filteredTasks, opts.view)
 		},
 	}
 
 	listCmd.Flags().StringVarP(&opts.projectFilter, "project", "p", "", "Filter tasks by project")
+	listCmd.Flags().StringSliceVar(&opts.tags, "tag", nil, "Only show tasks with this tag (repeatable)")
+	listCmd.Flags().StringSliceVar(&opts.excludedTags, "no-tag", nil, "Hide tasks with this tag (repeatable)")
 	listCmd.Flags().BoolVarP(&opts.showCompleted, "completed", "c", false, "Show completed tasks")
-	listCmd.Flags().StringVarP(&opts.sortBy, "sort", "s", "id", "Sort tasks by: id, priority, or due")
-	listCmd.Flags().StringVar(&opts.view, "view", "basic", "Set view format: basic or full")
+	listCmd.Flags().BoolVar(&opts.archived, "archived", false, "List archived tasks instead of the task list")
+	listCmd.Flags().BoolVar(&opts.readyOnly, "ready", false, "Only show pending or in-progress tasks that are not blocked by dependencies")
+	listCmd.Flags().StringSliceVar(&statusNames, "status", nil, "Only show tasks with this status: pending, in-progress, waiting, blocked, done or cancelled (repeatable)")
+	listCmd.Flags().StringVarP(&opts.sortBy, "sort", "s", "id", "Sort order, e.g. priority-,due+,project (+ ascending, - descending)")
+	listCmd.Flags().StringVar(&opts.view, "view", "basic", "Set view format: basic, full or tree")
+	listCmd.Flags().StringSliceVar(&opts.columns, "columns", nil, "Columns to show instead of a view, e.g. id,due,title:40 (a number limits the width)")
+	listCmd.Flags().StringVarP(&opts.output, "output", "o", outputTable, outputFlagUsage)
 
-	return listCmd
+	return acceptFilterArgs(taskStore, listCmd)
 }
 
 // filterTasks returns a new slice of tasks that match the filter criteria in opts.
-func filterTasks(tasks []*model.Task, opts *listOptions) []*model.Task {
+// lookup resolves dependencies when deciding whether a task is blocked.
+func filterTasks(tasks []*model.Task, opts *listOptions, lookup model.TaskLookup) []*model.Task {
 	filtered := make([]*model.Task, 0, len(tasks))
 	for _, task := range tasks {
-		if !opts.showCompleted && !task.CompletedAt.IsZero() {
+		// The archive holds nothing but done and cancelled tasks.
+		if !opts.showCompleted && !opts.archived && len(opts.statuses) == 0 && task.IsClosed() && (opts.filter == nil || !showsCompleted(opts.filter)) {
+			continue
+		}
+
+		if len(opts.statuses) > 0 && !slices.Contains(opts.statuses, task.State()) {
+			continue
+		}
+
+		if opts.filter != nil && !opts.filter.Match(task, lookup) {
+			continue
+		}
+
+		if opts.readyOnly && !isReady(task, lookup) {
 			continue
 		}
 
@@ -77,41 +199,40 @@ func filterTasks(tasks []*model.Task, opts *listOptions) []*model.Task {
 			continue
 		}
 
+		if !hasAllTags(task, opts.tags) || hasAnyTag(task, opts.excludedTags) {
+			continue
+		}
+
 		filtered = append(filtered, task)
 	}
 	return filtered
 }
 
-// sortTasks sorts the slice of tasks in-place based on the sortBy option.
-func sortTasks(tasks []*model.Task, opts *listOptions) {
-	switch strings.ToLower(opts.sortBy) {
-	case "priority":
-		sort.Slice(tasks, func(i, j int) bool {
-			return tasks[i].Priority > tasks[j].Priority
-		})
-	case "due":
-		sort.Slice(tasks, func(i, j int) bool {
-			// Handle tasks without due dates by sorting them last.
-			if tasks[i].DueDate.IsZero() {
-				return false
-			}
-			if tasks[j].DueDate.IsZero() {
-				return true
-			}
-			return tasks[i].DueDate.Before(tasks[j].DueDate)
-		})
-	case "id":
-		fallthrough // Fallthrough to a default case
-	default:
-		sort.Slice(tasks, func(i, j int) bool {
-			return tasks[i].ID < tasks[j].ID
-		})
+// hasAllTags reports whether the task carries every one of tags.
+func hasAllTags(task *model.Task, tags []string) bool {
+	for _, tag := range tags {
+		if !task.HasTag(tag) {
+			return false
+		}
 	}
+	return true
+}
+
+// hasAnyTag reports whether the task carries at least one of tags.
+func hasAnyTag(task *model.Task, tags []string) bool {
+	for _, tag := range tags {
+		if task.HasTag(tag) {
+			return true
+		}
+	}
+	return false
 }
 
 // DisplayManager handles the rendering of data to an output stream.
 type DisplayManager struct {
 	writer io.Writer
+	// env resolves related tasks and scores urgency. It may be left empty.
+	env taskEnv
 }
 
 // NewDisplayManager creates a new display manager.
@@ -121,39 +242,84 @@ func NewDisplayManager(w io.Writer) *DisplayManager {
 
 // RenderTasks orchestrates the conversion of tasks to a tabular format and prints them.
 func (dm *DisplayManager) RenderTasks(tasks []*model.Task, view string) error {
-	headers, rows := buildTableData(tasks, view)
+	var headers []string
+	var rows [][]string
+	if view == "tree" {
+		headers, rows = buildTreeData(tasks, dm.env.lookup)
+	} else {
+		headers, rows = buildTableData(tasks, view, dm.env)
+	}
 	if len(rows) == 0 {
 		return nil // Nothing to render
 	}
 	return dm.renderTable(headers, rows)
 }
 
+// RenderColumns prints tasks as a table with the given columns.
+func (dm *DisplayManager) RenderColumns(tasks []*model.Task, specs []columnSpec) error {
+	headers, rows := buildColumnData(tasks, specs, dm.env)
+	return dm.renderTable(headers, rows)
+}
+
 // buildTableData transforms tasks into headers and rows based on the selected view.
-func buildTableData(tasks []*model.Task, view string) (headers []string, rows [][]string) {
-	switch view {
-	case "basic":
-		headers = []string{"ID", "Title"}
-		rows = make([][]string, len(tasks))
-		for i, task := range tasks {
-			rows[i] = []string{
-				strconv.Itoa(task.ID),
-				task.Title,
-			}
+func buildTableData(tasks []*model.Task, view string, env taskEnv) (headers []string, rows [][]string) {
+	names, ok := viewColumns[view]
+	if !ok {
+		names = viewColumns["full"]
+	}
+	specs, _ := parseColumns(names)
+	return buildColumnData(tasks, specs, env)
+}
+
+// buildTreeData transforms tasks into rows where subtasks are nested below
+// their parent. Tasks whose parent is not among tasks are shown at the top level.
+func buildTreeData(tasks []*model.Task, lookup model.TaskLookup) (headers []string, rows [][]string) {
+	headers = []string{"ID", "Status", "Title"}
+
+	present := make(map[int]bool, len(tasks))
+	for _, task := range tasks {
+		present[task.ID] = true
+	}
+
+	visited := make(map[int]bool, len(tasks))
+	var walk func(task *model.Task, prefix string, branch string)
+	walk = func(task *model.Task, prefix string, branch string) {
+		if visited[task.ID] {
+			return
 		}
-	default: // "full" view
-		headers = []string{"ID", "Status", "Priority", "Due Date", "Project", "Title"}
-		rows = make([][]string, len(tasks))
-		for i, task := range tasks {
-			rows[i] = []string{
-				strconv.Itoa(task.ID),
-				getStatusIcon(task),
-				getPriorityString(task.Priority),
-				task.DueDate.Format("2006-01-02"),
-				task.Project,
-				task.Title,
+		visited[task.ID] = true
+		rows = append(rows, []string{
+			strconv.Itoa(task.ID),
+			getStatusIcon(task, lookup),
+			prefix + branch + task.Title,
+		})
+
+		childPrefix := prefix
+		switch branch {
+		case "├─ ":
+			childPrefix += "│  "
+		case "└─ ":
+			childPrefix += "   "
+		}
+		children := childrenOf(tasks, task.ID)
+		for i, child := range children {
+			if i == len(children)-1 {
+				walk(child, childPrefix, "└─ ")
+			} else {
+				walk(child, childPrefix, "├─ ")
 			}
 		}
 	}
+
+	for _, task := range tasks {
+		if task.ParentID == 0 || !present[task.ParentID] {
+			walk(task, "", "")
+		}
+	}
+	// Tasks caught in a parent cycle have no root; show them flat.
+	for _, task := range tasks {
+		walk(task, "", "")
+	}
 	return headers, rows
 }
 
@@ -176,16 +342,47 @@ func (dm *DisplayManager) renderTable(headers []string, rows [][]string) error {
 
 }
 
-func getStatusIcon(task *model.Task) string {
-	if !task.CompletedAt.IsZero() {
+// isReady reports whether a task can be worked on now: it is pending or in
+// progress and no dependency blocks it.
+func isReady(task *model.Task, lookup model.TaskLookup) bool {
+	state := task.State()
+	return (state == model.StatusPending || state == model.StatusInProgress) && !task.IsBlocked(lookup)
+}
+
+func getStatusIcon(task *model.Task, lookup model.TaskLookup) string {
+	switch task.State() {
+	case model.StatusDone:
 		return "✅"
+	case model.StatusCancelled:
+		return "✖️"
+	}
+	if task.IsActive() {
+		return "▶"
+	}
+	if task.State() == model.StatusBlocked || task.IsBlocked(lookup) {
+		return "⛔"
 	}
 	if task.IsOverdue() {
 		return "⚠️"
 	}
+	switch task.State() {
+	case model.StatusWaiting:
+		return "💤"
+	case model.StatusInProgress:
+		return "🔨"
+	}
 	return "⏳"
 }
 
+// formatTags renders tags the way they are typed on the command line.
+func formatTags(tags []string) string {
+	formatted := make([]string, len(tags))
+	for i, tag := range tags {
+		formatted[i] = "+" + tag
+	}
+	return strings.Join(formatted, " ")
+}
+
 func getPriorityString(p model.Priority) string {
 	switch p {
 	case model.High:
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/kevin7254/task/store"
+	"github.com/spf13/cobra"
+)
+
+func NewMigrateCmd(taskStore store.TaskRepository) *cobra.Command {
+	var dryRun bool
+	cobraCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrade the task store to the current format",
+		Long: `Upgrade a task store written by an older version of task to the
+current format. The original file is backed up before it is rewritten.
+
+JSON stores are also upgraded automatically the next time a task is changed.
+SQLite stores are upgraded as soon as they are opened, so for them this
+reports the upgrade that was just applied, even with --dry-run.
+
+Examples:
+  task migrate --dry-run  # Show what would change
+  task migrate            # Back up and rewrite the store`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if upgrader, ok := taskStore.(store.Upgrader); ok {
+				if upgraded := upgrader.Upgraded(); upgraded != nil {
+					cmd.Printf("Migrated %d task(s) from schema version %d to %d when the store was opened:\n", upgraded.TaskCount, upgraded.FromVersion, upgraded.ToVersion)
+					printMigrationSteps(cmd, upgraded)
+					cmd.Printf("The original was backed up to %s\n", upgraded.BackupPath)
+					return nil
+				}
+			}
+
+			migrator, ok := taskStore.(store.Migrator)
+			if !ok {
+				cmd.Printf("Task store is up to date (schema version %d).\n", store.CurrentSchemaVersion)
+				return nil
+			}
+
+			plan := migrator.MigrationPlan()
+			if plan == nil {
+				cmd.Printf("Task store is up to date (schema version %d).\n", store.CurrentSchemaVersion)
+				return nil
+			}
+
+			if dryRun {
+				cmd.Printf("Would migrate %d task(s) from schema version %d to %d:\n", plan.TaskCount, plan.FromVersion, plan.ToVersion)
+				printMigrationSteps(cmd, plan)
+				cmd.Printf("The original would be backed up to %s\n", plan.BackupPath)
+				return nil
+			}
+
+			applied, err := migrator.Migrate()
+			if err != nil {
+				return fmt.Errorf("failed to migrate task store: %w", err)
+			}
+			if applied == nil {
+				cmd.Printf("Task store is up to date (schema version %d).\n", store.CurrentSchemaVersion)
+				return nil
+			}
+
+			cmd.Printf("Migrated %d task(s) from schema version %d to %d:\n", applied.TaskCount, applied.FromVersion, applied.ToVersion)
+			printMigrationSteps(cmd, applied)
+			cmd.Printf("The original was backed up to %s\n", applied.BackupPath)
+			return nil
+		},
+	}
+	cobraCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report what would change without writing anything")
+	return cobraCmd
+}
+
+func printMigrationSteps(cmd *cobra.Command, plan *store.MigrationPlan) {
+	for _, step := range plan.Steps {
+		cmd.Printf("  v%d -> v%d: %s\n", step.From, step.From+1, step.Description)
+	}
+}
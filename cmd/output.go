@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/kevin7254/task/dateparse"
+	"github.com/kevin7254/task/model"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats accepted by --output.
+const (
+	outputTable    = "table"
+	outputJSON     = "json"
+	outputNDJSON   = "ndjson"
+	outputYAML     = "yaml"
+	outputCSV      = "csv"
+	outputTSV      = "tsv"
+	outputTemplate = "template"
+)
+
+// outputFlagUsage describes the --output flag of list and show.
+const outputFlagUsage = "Output format: table, json, ndjson, yaml, csv, tsv or template='{{.ID}} {{.Title}}'"
+
+// taskRecord is the stable, machine-readable form of a task used by every
+// --output format except table. It is documented by docs/task.schema.json:
+// fields may be added in later versions but are never renamed or removed.
+type taskRecord struct {
+	ID           int                `json:"id" yaml:"id"`
+	UUID         string             `json:"uuid" yaml:"uuid"`
+	Title        string             `json:"title" yaml:"title"`
+	Description  string             `json:"description" yaml:"description"`
+	Project      string             `json:"project" yaml:"project"`
+	Priority     int                `json:"priority" yaml:"priority"`
+	PriorityName string             `json:"priority_name" yaml:"priority_name"`
+	Status       string             `json:"status" yaml:"status"`
+	Blocked      bool               `json:"blocked" yaml:"blocked"`
+	Overdue      bool               `json:"overdue" yaml:"overdue"`
+	Active       bool               `json:"active" yaml:"active"`
+	Due          *time.Time         `json:"due" yaml:"due"`
+	Created      time.Time          `json:"created" yaml:"created"`
+	Completed    *time.Time         `json:"completed" yaml:"completed"`
+	TimeSpent    int64              `json:"time_spent_minutes" yaml:"time_spent_minutes"`
+	Tags         []string           `json:"tags" yaml:"tags"`
+	Parent       *int               `json:"parent" yaml:"parent"`
+	DependsOn    []int              `json:"depends_on" yaml:"depends_on"`
+	Recur        *string            `json:"recur" yaml:"recur"`
+	Annotations  []annotationRecord `json:"annotations" yaml:"annotations"`
+}
+
+// annotationRecord is the output form of an annotation.
+type annotationRecord struct {
+	Entry       time.Time `json:"entry" yaml:"entry"`
+	Description string    `json:"description" yaml:"description"`
+}
+
+// recordColumns are the CSV and TSV columns, in order.
+var recordColumns = []string{
+	"id", "title", "description", "project", "priority", "priority_name", "status",
+	"blocked", "overdue", "due", "created", "completed", "time_spent_minutes",
+	"tags", "parent", "depends_on", "recur", "active", "annotations",
+	"uuid",
+}
+
+// newTaskRecord converts a task into its output form. lookup resolves
+// dependencies to decide whether the task is blocked.
+func newTaskRecord(task *model.Task, lookup model.TaskLookup) taskRecord {
+	record := taskRecord{
+		ID:           task.ID,
+		UUID:         task.UUID,
+		Title:        task.Title,
+		Description:  task.Description,
+		Project:      task.Project,
+		Priority:     int(task.Priority),
+		PriorityName: strings.ToLower(getPriorityString(task.Priority)),
+		Status:       outputStatus(task.State()),
+		Blocked:      task.State() == model.StatusBlocked || (!task.IsClosed() && task.IsBlocked(lookup)),
+		Overdue:      !task.IsClosed() && task.IsOverdue(),
+		Active:       task.IsActive(),
+		Created:      task.CreatedAt,
+		TimeSpent:    task.TimeSpent(),
+		Tags:         append([]string{}, task.Tags...),
+		DependsOn:    append([]int{}, task.DependsOn...),
+		Annotations:  make([]annotationRecord, len(task.Annotations)),
+	}
+	for i, annotation := range task.Annotations {
+		record.Annotations[i] = annotationRecord{Entry: annotation.Entry, Description: annotation.Description}
+	}
+	if !task.CompletedAt.IsZero() {
+		completed := task.CompletedAt
+		record.Completed = &completed
+	}
+	if !task.DueDate.IsZero() {
+		due := task.DueDate
+		record.Due = &due
+	}
+	if task.ParentID != 0 {
+		parent := task.ParentID
+		record.Parent = &parent
+	}
+	if task.Recur != nil {
+		rule := task.Recur.String()
+		record.Recur = &rule
+	}
+	return record
+}
+
+// outputStatus names a status in output records. Done is written as
+// "completed", the name used before tasks had a workflow status.
+func outputStatus(status model.Status) string {
+	if status == model.StatusDone {
+		return "completed"
+	}
+	return string(status)
+}
+
+// csvRow returns the record's values in recordColumns order.
+func (r taskRecord) csvRow() []string {
+	optionalTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	parent := ""
+	if r.Parent != nil {
+		parent = strconv.Itoa(*r.Parent)
+	}
+	recur := ""
+	if r.Recur != nil {
+		recur = *r.Recur
+	}
+	depends := make([]string, len(r.DependsOn))
+	for i, id := range r.DependsOn {
+		depends[i] = strconv.Itoa(id)
+	}
+	// One annotation per line, each prefixed with its time.
+	annotations := make([]string, len(r.Annotations))
+	for i, annotation := range r.Annotations {
+		annotations[i] = annotation.Entry.Format(time.RFC3339) + " " + annotation.Description
+	}
+	return []string{
+		strconv.Itoa(r.ID), r.Title, r.Description, r.Project,
+		strconv.Itoa(r.Priority), r.PriorityName, r.Status,
+		strconv.FormatBool(r.Blocked), strconv.FormatBool(r.Overdue),
+		optionalTime(r.Due), r.Created.Format(time.RFC3339), optionalTime(r.Completed),
+		strconv.FormatInt(r.TimeSpent, 10), strings.Join(r.Tags, ","),
+		parent, strings.Join(depends, ","), recur, strconv.FormatBool(r.Active),
+		strings.Join(annotations, "\n"), r.UUID,
+	}
+}
+
+// outputFormat is a parsed --output value.
+type outputFormat struct {
+	name string
+	tmpl *template.Template
+}
+
+// templateFuncs are available to --output template=...
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+	"date": func(t any) string {
+		switch v := t.(type) {
+		case time.Time:
+			return dateparse.Format(v)
+		case *time.Time:
+			if v != nil {
+				return dateparse.Format(*v)
+			}
+		}
+		return ""
+	},
+}
+
+// parseOutputFormat parses the value of --output.
+func parseOutputFormat(value string) (outputFormat, error) {
+	name, text, hasTemplate := strings.Cut(value, "=")
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch name {
+	case "", outputTable:
+		return outputFormat{name: outputTable}, nil
+	case outputJSON, outputNDJSON, outputYAML, outputCSV, outputTSV:
+		if hasTemplate {
+			return outputFormat{}, fmt.Errorf("output format %q does not take a value", name)
+		}
+		return outputFormat{name: name}, nil
+	case outputTemplate:
+		if !hasTemplate || text == "" {
+			return outputFormat{}, fmt.Errorf("template output needs a template, e.g. --output template='{{.ID}} {{.Title}}'")
+		}
+		tmpl, err := template.New("output").Funcs(templateFuncs).Parse(text)
+		if err != nil {
+			return outputFormat{}, fmt.Errorf("invalid output template: %w", err)
+		}
+		return outputFormat{name: name, tmpl: tmpl}, nil
+	default:
+		return outputFormat{}, fmt.Errorf("unknown output format %q (expected table, json, ndjson, yaml, csv, tsv or template=...)", value)
+	}
+}
+
+// isTable reports whether the human-readable table output was chosen.
+func (f outputFormat) isTable() bool {
+	return f.name == outputTable
+}
+
+// writeTasks writes tasks in a machine-readable format. With single set, as
+// for "task show", JSON and YAML hold one object instead of a list.
+func (f outputFormat) writeTasks(w io.Writer, tasks []*model.Task, lookup model.TaskLookup, single bool) error {
+	records := make([]taskRecord, len(tasks))
+	for i, task := range tasks {
+		records[i] = newTaskRecord(task, lookup)
+	}
+
+	var value any = records
+	if single && len(records) == 1 {
+		value = records[0]
+	}
+
+	switch f.name {
+	case outputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case outputNDJSON:
+		encoder := json.NewEncoder(w)
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	case outputYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(value); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case outputCSV, outputTSV:
+		writer := csv.NewWriter(w)
+		if f.name == outputTSV {
+			writer.Comma = '\t'
+		}
+		if err := writer.Write(recordColumns); err != nil {
+			return err
+		}
+		for _, record := range records {
+			if err := writer.Write(record.csvRow()); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	case outputTemplate:
+		for _, record := range records {
+			if err := f.tmpl.Execute(w, record); err != nil {
+				return fmt.Errorf("failed to render output template: %w", err)
+			}
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("output format %q cannot be written as records", f.name)
+	}
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/kevin7254/task/dateparse"
+	"github.com/kevin7254/task/store"
+	"github.com/spf13/cobra"
+)
+
+// NewRecurCmd creates the 'recur' command, which lists recurring tasks.
+func NewRecurCmd(taskStore store.TaskRepository) *cobra.Command {
+	recurCmd := &cobra.Command{
+		Use:   "recur",
+		Short: "List recurring tasks",
+		Long: `List recurring tasks. The pending instance of a recurring task is the
+template for the next one: completing it with "task do" creates the next
+instance, and stopping it ends the series.
+
+Examples:
+  task recur          # List recurring tasks and their rules
+  task recur stop 4   # Stop task 4 from recurring`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var rows [][]string
+			tasks := taskStore.ListAllTasks()
+			sort.Slice(tasks, func(i, j int) bool {
+				return tasks[i].ID < tasks[j].ID
+			})
+			for _, task := range tasks {
+				if task.Recur == nil {
+					continue
+				}
+				rows = append(rows, []string{
+					strconv.Itoa(task.ID),
+					task.Recur.String(),
+					dateparse.Format(task.DueDate),
+					task.Title,
+				})
+			}
+
+			if len(rows) == 0 {
+				cmd.Println("No recurring tasks found.")
+				return nil
+			}
+			dm := NewDisplayManager(cmd.OutOrStdout())
+			return dm.renderTable([]string{"ID", "Rule", "Next Due", "Title"}, rows)
+		},
+	}
+	recurCmd.AddCommand(newRecurStopCmd(taskStore))
+	return recurCmd
+}
+
+func newRecurStopCmd(taskStore store.TaskRepository) *cobra.Command {
+	return &cobra.Command{
+		Use:   "stop ID [ID...]",
+		Short: "Stop task(s) from recurring",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, arg := range args {
+				task, resolveErr := resolveTask(taskStore, arg)
+				if resolveErr != nil {
+					return resolveErr
+				}
+				id := task.ID
+				if task.Recur == nil {
+					return fmt.Errorf("task %d is not recurring", id)
+				}
+
+				task.Recur = nil
+				if err := taskStore.UpdateTask(task); err != nil {
+					return fmt.Errorf("failed to update task %d: %w", id, err)
+				}
+				cmd.Printf("Stopped recurrence of task %d: %s\n", id, task.Title)
+			}
+			return nil
+		},
+	}
+}
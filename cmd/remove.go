@@ -4,45 +4,64 @@ import (
 	"fmt"
 	"github.com/kevin7254/task/store"
 	"github.com/spf13/cobra"
-	"strconv"
 )
 
 func NewRemoveCmd(store store.TaskRepository) *cobra.Command {
+	var yes bool
 	cobraCmd := &cobra.Command{
-		Use:   "remove ID [ID...]",
+		Use:   "remove ID [ID...] | FILTER",
 		Short: "Remove task(s)",
 		Long: `Remove one or more tasks totally. This is different
 compared to "task do" in that this removes them totally, they will not
-included in any stats in any way.
+included in any stats in any way. Other tasks that depended on a removed
+task have that dependency removed, and its subtasks become top-level tasks.
+Instead of IDs, a filter selects every
+pending task that matches it (see "task list --help" for the syntax). A
+snapshot of all tasks is saved first (see "task backup").
 
 Examples:
   task remove 1           # Remove task with ID 1 totally
-  task remove 1 2 3       # Remove multiple totally`,
+  task remove 1 2 3       # Remove multiple totally
+  task remove project:old # Remove all pending tasks in the 'old' project`,
 		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			var ids []int
-			for _, arg := range args {
-				id, err := strconv.Atoi(arg)
-				if err != nil {
-					return fmt.Errorf("invalid task ID: %s", arg)
-				}
-				ids = append(ids, id)
+			tasks, selectErr := selectTasks(cmd, store, args, yes, "Remove")
+			if selectErr != nil {
+				return selectErr
 			}
-
-			for _, id := range ids {
-				task := store.GetTaskByID(id)
-				if task == nil {
-					return fmt.Errorf("task with ID %d not found", id)
+			if len(tasks) > 0 {
+				if _, err := takeSnapshot(cmd, store); err != nil {
+					return err
 				}
+			}
 
+			for _, task := range tasks {
+				id := task.ID
 				if err := store.DeleteTask(id); err != nil {
 					return fmt.Errorf("failed to remove task %d: %w", id, err)
 				}
 
 				cmd.Printf("Remove task %d: %s\n", id, task.Title)
+
+				changed, err := removeDependencyOn(store, id)
+				for _, dependent := range changed {
+					cmd.Printf("Removed dependency on task %d from task %d: %s\n", id, dependent.ID, dependent.Title)
+				}
+				if err != nil {
+					return err
+				}
+
+				detached, err := detachSubtasksOf(store, id)
+				for _, subtask := range detached {
+					cmd.Printf("Removed parent task %d from task %d: %s\n", id, subtask.ID, subtask.Title)
+				}
+				if err != nil {
+					return err
+				}
 			}
 			return nil
 		},
 	}
-	return cobraCmd
+	cobraCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Don't ask for confirmation when a filter matches many tasks")
+	return acceptFilterArgs(store, cobraCmd)
 }
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kevin7254/task/config"
+	"github.com/kevin7254/task/store"
+	"github.com/spf13/cobra"
+)
+
+// NewReportCmd creates the 'report' command, which runs reports saved in the
+// configuration file.
+func NewReportCmd(taskStore store.TaskRepository) *cobra.Command {
+	var output string
+	cobraCmd := &cobra.Command{
+		Use:   "report [NAME [FILTER]]",
+		Short: "Run a saved report",
+		Long: `Run a report defined under "reports" in ~/.task/config.json. A report
+names the columns to show, a filter, a sort order and a limit. Any filter
+given on the command line narrows the report further.
+
+Without a name, the available reports are listed.
+
+Examples:
+  task report                  # List the available reports
+  task report next             # Run the built-in 'next' report
+  task report standup +review  # Run 'standup', only tasks tagged review`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			reports := configOf(cmd).Reports
+			if len(args) == 0 {
+				return listReports(cmd, reports)
+			}
+
+			report, ok := reports[args[0]]
+			if !ok {
+				return fmt.Errorf("unknown report %q (available: %s)", args[0], strings.Join(reportNames(reports), ", "))
+			}
+			return runReport(cmd, taskStore, args[0], report, args[1:], output)
+		},
+	}
+	cobraCmd.Flags().StringVarP(&output, "output", "o", outputTable, outputFlagUsage)
+	return acceptFilterArgs(taskStore, cobraCmd)
+}
+
+// NewNextCmd creates the 'next' command, a shortcut for "task report next".
+func NewNextCmd(taskStore store.TaskRepository) *cobra.Command {
+	var output string
+	var limit int
+	cobraCmd := &cobra.Command{
+		Use:   "next [FILTER]",
+		Short: "Show the tasks to work on next",
+		Long: `Show the tasks to work on next, most urgent first. This runs the 'next'
+report, which can be changed under "reports" in ~/.task/config.json. The
+urgency of a task combines its priority, due date, age, tags and whether it is
+blocked; the weight of each is set under "urgency" in the same file, and
+"task show ID --explain-urgency" shows how a score was reached.
+
+Examples:
+  task next               # The most urgent tasks that can be started now
+  task next -n 3          # Only the top three
+  task next project:work  # The same, for the 'work' project only`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			report, ok := configOf(cmd).Reports["next"]
+			if !ok {
+				return fmt.Errorf("the 'next' report is not defined")
+			}
+			if cmd.Flags().Changed("limit") {
+				if limit < 1 {
+					return fmt.Errorf("limit must be at least 1")
+				}
+				report.Limit = limit
+			}
+			return runReport(cmd, taskStore, "next", report, args, output)
+		},
+	}
+	cobraCmd.Flags().IntVarP(&limit, "limit", "n", 0, "Number of tasks to show (default from the 'next' report)")
+	cobraCmd.Flags().StringVarP(&output, "output", "o", outputTable, outputFlagUsage)
+	return acceptFilterArgs(taskStore, cobraCmd)
+}
+
+// runReport shows the tasks selected by report, narrowed by the filter args.
+func runReport(cmd *cobra.Command, taskStore store.TaskRepository, name string, report config.ReportConfig, args []string, output string) error {
+	format, formatErr := parseOutputFormat(output)
+	if formatErr != nil {
+		return formatErr
+	}
+
+	columnNames := report.Columns
+	if len(columnNames) == 0 {
+		columnNames = viewColumns["full"]
+	}
+	specs, columnsErr := parseColumns(columnNames)
+	if columnsErr != nil {
+		return fmt.Errorf("report %q: %w", name, columnsErr)
+	}
+
+	sortKeys, sortErr := parseSortKeys(report.Sort)
+	if sortErr != nil {
+		return fmt.Errorf("report %q: %w", name, sortErr)
+	}
+
+	opts := &listOptions{}
+	var filterArgs []string
+	if report.Filter != "" {
+		filterArgs = append(filterArgs, "("+report.Filter+")")
+	}
+	filterArgs = append(filterArgs, args...)
+	if len(filterArgs) > 0 {
+		expr, err := parseQuery(filterArgs)
+		if err != nil {
+			return fmt.Errorf("report %q: %w", name, err)
+		}
+		opts.filter = expr
+	}
+
+	env := newTaskEnv(cmd, taskStore.GetTaskByID)
+	tasks := filterTasks(taskStore.ListAllTasks(), opts, taskStore.GetTaskByID)
+	sortTasks(tasks, sortKeys, env)
+	if report.Limit > 0 && len(tasks) > report.Limit {
+		tasks = tasks[:report.Limit]
+	}
+
+	if !format.isTable() {
+		return format.writeTasks(cmd.OutOrStdout(), tasks, taskStore.GetTaskByID, false)
+	}
+	if len(tasks) == 0 {
+		cmd.Printf("No tasks match report %q.\n", name)
+		return nil
+	}
+	dm := NewDisplayManager(cmd.OutOrStdout())
+	dm.env = env
+	return dm.RenderColumns(tasks, specs)
+}
+
+// listReports prints the name, description and filter of every report.
+func listReports(cmd *cobra.Command, reports map[string]config.ReportConfig) error {
+	if len(reports) == 0 {
+		cmd.Println("No reports defined.")
+		return nil
+	}
+	var rows [][]string
+	for _, name := range reportNames(reports) {
+		report := reports[name]
+		rows = append(rows, []string{name, report.Description, report.Filter})
+	}
+	dm := NewDisplayManager(cmd.OutOrStdout())
+	return dm.renderTable([]string{"Name", "Description", "Filter"}, rows)
+}
+
+// reportNames returns the names of the reports, sorted.
+func reportNames(reports map[string]config.ReportConfig) []string {
+	names := make([]string, 0, len(reports))
+	for name := range reports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -5,16 +5,44 @@ import (
 	"github.com/spf13/cobra"
 )
 
-func NewRootCmd(store store.TaskRepository) *cobra.Command {
+func NewRootCmd(store store.TaskRepository, opts ...Option) *cobra.Command {
 	rootCmd := &cobra.Command{
 		Use:   "task",
 		Short: "Task is a CLI tool for managing tasks",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			autoArchive(store, cmd)
+			beginOperation(store, cmd, args)
+		},
 	}
 	rootCmd.AddCommand(NewAddCmd(store))
 	rootCmd.AddCommand(NewDoCmd(store))
+	rootCmd.AddCommand(NewCancelCmd(store))
+	rootCmd.AddCommand(NewWaitCmd(store))
 	rootCmd.AddCommand(NewListCmd(store))
 	rootCmd.AddCommand(NewRemoveCmd(store))
+	rootCmd.AddCommand(NewClearCmd(store))
 	rootCmd.AddCommand(NewEditCmd(store))
 	rootCmd.AddCommand(NewShowCmd(store))
+	rootCmd.AddCommand(NewAnnotateCmd(store))
+	rootCmd.AddCommand(NewDenotateCmd(store))
+	rootCmd.AddCommand(NewArchiveCmd(store))
+	rootCmd.AddCommand(NewUnarchiveCmd(store))
+	rootCmd.AddCommand(NewMigrateCmd(store))
+	rootCmd.AddCommand(NewTagsCmd(store))
+	rootCmd.AddCommand(NewRecurCmd(store))
+	rootCmd.AddCommand(NewUndoCmd(store))
+	rootCmd.AddCommand(NewRedoCmd(store))
+	rootCmd.AddCommand(NewLogCmd(store))
+	rootCmd.AddCommand(NewReportCmd(store))
+	rootCmd.AddCommand(NewNextCmd(store))
+	rootCmd.AddCommand(NewStartCmd(store))
+	rootCmd.AddCommand(NewStopCmd(store))
+	rootCmd.AddCommand(NewTrackCmd(store))
+	rootCmd.AddCommand(NewTimesheetCmd(store))
+	rootCmd.AddCommand(NewBackupCmd(store))
+	rootCmd.AddCommand(NewRestoreCmd(store))
+	for _, opt := range opts {
+		opt(rootCmd)
+	}
 	return rootCmd
 }
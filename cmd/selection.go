@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kevin7254/task/model"
+	"github.com/kevin7254/task/query"
+	"github.com/kevin7254/task/store"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// parseQuery parses the filter given as command arguments.
+func parseQuery(args []string) (query.Expr, error) {
+	expr, err := query.Parse(strings.Join(args, " "), time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+	return expr, nil
+}
+
+// acceptFilterArgs lets c take a filter with "-tag" terms, such as
+// "task list +review -blocked", which the flag parser would reject as
+// unknown shorthand flags. Flag parsing stops at the first argument, and
+// flags given after the filter are picked out and parsed before c runs:
+// "--name", "--name=value" and single-letter "-x", each followed by its value
+// if it takes one. Anything else starting with "-" is a filter term; "--"
+// still ends the flags.
+func acceptFilterArgs(taskStore store.TaskRepository, c *cobra.Command) *cobra.Command {
+	c.Flags().SetInterspersed(false)
+	run := c.RunE
+	c.RunE = func(cmd *cobra.Command, args []string) error {
+		terms, flags := splitFilterArgs(cmd.Flags(), args)
+		if len(flags) > 0 {
+			if err := cmd.Flags().Parse(flags); err != nil {
+				return err
+			}
+			if help, _ := cmd.Flags().GetBool("help"); help {
+				return cmd.Help()
+			}
+			// The operation was named before these flags were known.
+			beginOperation(taskStore, cmd, terms)
+		}
+		return run(cmd, terms)
+	}
+	return c
+}
+
+// splitFilterArgs separates the flags among args, with their values, from
+// the filter terms (see acceptFilterArgs).
+func splitFilterArgs(flags *pflag.FlagSet, args []string) (terms []string, flagArgs []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		var flag *pflag.Flag
+		switch {
+		case arg == "--":
+			return append(terms, args[i+1:]...), flagArgs
+		case strings.HasPrefix(arg, "--"):
+			name, _, hasValue := strings.Cut(arg[2:], "=")
+			if flag = flags.Lookup(name); hasValue {
+				flag = nil
+			}
+		case len(arg) == 2 && arg[0] == '-' && arg[1] != '-':
+			flag = flags.ShorthandLookup(arg[1:])
+			if flag == nil {
+				// Unknown single letters are reported by the flag parser.
+				flagArgs = append(flagArgs, arg)
+				continue
+			}
+		default:
+			terms = append(terms, arg)
+			continue
+		}
+		flagArgs = append(flagArgs, arg)
+		if flag != nil && flag.NoOptDefVal == "" && i+1 < len(args) {
+			i++
+			flagArgs = append(flagArgs, args[i])
+		}
+	}
+	return terms, flagArgs
+}
+
+// showsCompleted reports whether a filter asks about completion itself, in
+// which case completed tasks are not hidden by default.
+func showsCompleted(expr query.Expr) bool {
+	return query.References(expr, "status") || query.References(expr, "completed")
+}
+
+// onlyText reports whether expr consists of plain text terms only.
+func onlyText(expr query.Expr) bool {
+	switch e := expr.(type) {
+	case *query.And:
+		return onlyText(e.Left) && onlyText(e.Right)
+	case *query.Or:
+		return onlyText(e.Left) && onlyText(e.Right)
+	case *query.Not:
+		return onlyText(e.Expr)
+	case *query.Text:
+		return true
+	default:
+		return false
+	}
+}
+
+// selectTasks resolves the tasks a bulk command acts on. args are either task
+// references (IDs or UUID prefixes) or a filter such as "project:work
+// +review". A filter skips completed tasks unless it mentions status or
+// completion, and must contain a field condition, tag or ID so that a
+// mistyped ID never matches by title. When it matches more tasks than the
+// configured threshold, the user is asked to confirm unless yes is set; if
+// they decline, no tasks are returned.
+func selectTasks(cmd *cobra.Command, taskStore store.TaskRepository, args []string, yes bool, verb string) ([]*model.Task, error) {
+	if !slices.ContainsFunc(args, func(arg string) bool { return !isTaskRef(arg) }) {
+		tasks := make([]*model.Task, 0, len(args))
+		for _, arg := range args {
+			task, err := resolveTask(taskStore, arg)
+			if err != nil {
+				return nil, err
+			}
+			tasks = append(tasks, task)
+		}
+		return tasks, nil
+	}
+
+	expr, parseErr := parseQuery(args)
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	if onlyText(expr) {
+		for _, arg := range args {
+			if _, err := strconv.Atoi(arg); err != nil {
+				return nil, fmt.Errorf("invalid task ID: %s", arg)
+			}
+		}
+	}
+
+	candidates := taskStore.ListAllTasks()
+	if !showsCompleted(expr) {
+		candidates = pendingTasks(candidates)
+	}
+	tasks := query.Filter(candidates, expr, taskStore.GetTaskByID)
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].ID < tasks[j].ID
+	})
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("no tasks match %q", strings.Join(args, " "))
+	}
+
+	if threshold := configOf(cmd).Bulk.ConfirmThreshold; len(tasks) > threshold && !yes {
+		for _, task := range tasks {
+			cmd.Printf("  %d: %s\n", task.ID, task.Title)
+		}
+		if !confirm(cmd, fmt.Sprintf("%s %d tasks?", verb, len(tasks))) {
+			cmd.Println("Aborted; no tasks were changed.")
+			return nil, nil
+		}
+	}
+	return tasks, nil
+}
+
+// isTaskRef reports whether arg refers to a single task: a task ID, or a
+// prefix of at least model.ShortUUIDLength characters of a task's UUID. A
+// number is always taken as an ID.
+func isTaskRef(arg string) bool {
+	if _, err := strconv.Atoi(arg); err == nil {
+		return true
+	}
+	return isUUIDPrefix(arg)
+}
+
+// isUUIDPrefix reports whether s could be the start of a UUID and is long
+// enough to be taken as one rather than as a word.
+func isUUIDPrefix(s string) bool {
+	if len(s) < model.ShortUUIDLength {
+		return false
+	}
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF-", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveTask returns the task that ref refers to: a task ID or a UUID
+// prefix (see isTaskRef).
+func resolveTask(taskStore store.TaskRepository, ref string) (*model.Task, error) {
+	if id, err := strconv.Atoi(ref); err == nil {
+		task := taskStore.GetTaskByID(id)
+		if task == nil {
+			return nil, fmt.Errorf("task with ID %d not found", id)
+		}
+		return task, nil
+	}
+	if !isUUIDPrefix(ref) {
+		return nil, fmt.Errorf("invalid task ID: %s", ref)
+	}
+
+	prefix := strings.ToLower(ref)
+	var matches []*model.Task
+	for _, task := range taskStore.ListAllTasks() {
+		if strings.HasPrefix(task.UUID, prefix) {
+			matches = append(matches, task)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no task has a UUID starting with %s", ref)
+	case 1:
+		return matches[0], nil
+	default:
+		slices.SortFunc(matches, func(a, b *model.Task) int { return a.ID - b.ID })
+		ids := make([]string, len(matches))
+		for i, task := range matches {
+			ids[i] = strconv.Itoa(task.ID)
+		}
+		return nil, fmt.Errorf("UUID prefix %s is ambiguous; it matches tasks %s", ref, strings.Join(ids, ", "))
+	}
+}
+
+// parseTaskRefFlag resolves a task reference given as a flag value to a task
+// ID. A number is returned as given, so callers can report a missing task in
+// their own words.
+func parseTaskRefFlag(taskStore store.TaskRepository, value string) (int, error) {
+	if id, err := strconv.Atoi(value); err == nil {
+		return id, nil
+	}
+	task, err := resolveTask(taskStore, value)
+	if err != nil {
+		return 0, err
+	}
+	return task.ID, nil
+}
+
+// pendingTasks returns the tasks that are not done or cancelled.
+func pendingTasks(tasks []*model.Task) []*model.Task {
+	pending := make([]*model.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if !task.IsClosed() {
+			pending = append(pending, task)
+		}
+	}
+	return pending
+}
+
+// confirm asks a yes/no question on the command's input, defaulting to no.
+func confirm(cmd *cobra.Command, question string) bool {
+	cmd.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
@@ -2,35 +2,130 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/kevin7254/task/model"
 	"github.com/kevin7254/task/store"
 	"github.com/spf13/cobra"
 	"strconv"
+	"time"
 )
 
 func NewShowCmd(store store.TaskRepository) *cobra.Command {
+	var output string
+	var explainUrgency bool
 	cobraCmd := &cobra.Command{
 		Use:   "show [ID]",
 		Short: "Show (all) info about a specific task",
-		Args:  cobra.MinimumNArgs(1),
+		Long: `Show all information about a task. Tasks that were moved to the archive
+are found there.
+
+Examples:
+  task show 1           # Show task 1
+  task show 1 -o json   # Show task 1 as a JSON object
+  task show 1 --explain-urgency  # Show how task 1's urgency is computed`,
+		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) != 1 {
 				return fmt.Errorf("exactly one task ID must be provided")
 			}
 
-			id, atoiErr := strconv.Atoi(args[0])
-			if atoiErr != nil {
-				return fmt.Errorf("invalid task ID: %s", args[0])
+			format, formatErr := parseOutputFormat(output)
+			if formatErr != nil {
+				return formatErr
 			}
 
-			task := store.GetTaskByID(id)
-			if task == nil {
-				return fmt.Errorf("task with ID %d not found", id)
+			allTasks := store.ListAllTasks()
+			lookup := store.GetTaskByID
+			archived := false
+			task, resolveErr := resolveTask(store, args[0])
+			if resolveErr != nil && hasArchive(cmd) {
+				// Archived tasks are shown as if they were still in the list.
+				archive, err := archiveOf(cmd)
+				if err != nil {
+					return err
+				}
+				if archivedTask, err := resolveTask(archive, args[0]); err == nil {
+					task, resolveErr, archived = archivedTask, nil, true
+					allTasks = append(allTasks, archive.ListAllTasks()...)
+					lookup = lookupWithArchive(store, archive)
+				}
+			}
+			if resolveErr != nil {
+				return resolveErr
+			}
+
+			if explainUrgency && !format.isTable() {
+				return fmt.Errorf("--explain-urgency cannot be combined with --output")
+			}
+			if !format.isTable() {
+				return format.writeTasks(cmd.OutOrStdout(), []*model.Task{task}, lookup, true)
 			}
 
-			fmt.Println(task)
+			cmd.Println(task)
+			cmd.Printf("UUID: %s\n", task.UUID)
+			if archived {
+				cmd.Printf("Archived: %s\n", task.ArchivedAt.Format("2006-01-02 15:04"))
+			}
+			if task.Recur != nil {
+				cmd.Printf("Recurs: %s\n", task.Recur)
+			}
+			if task.ParentID != 0 {
+				if parent := lookup(task.ParentID); parent != nil {
+					cmd.Printf("Parent: %d %s\n", parent.ID, parent.Title)
+				}
+			}
+			if len(task.DependsOn) > 0 {
+				cmd.Printf("Depends on: %s\n", formatDependencies(task, lookup))
+			}
+			if task.State() == model.StatusBlocked || (!task.IsClosed() && task.IsBlocked(lookup)) {
+				cmd.Println("Blocked: yes")
+			}
+			if done, total := subtaskProgress(allTasks, task.ID); total > 0 {
+				cmd.Printf("Subtasks: %d/%d done\n", done, total)
+				for _, child := range childrenOf(allTasks, task.ID) {
+					cmd.Printf("  %s %d %s\n", getStatusIcon(child, lookup), child.ID, child.Title)
+				}
+			}
+			if len(task.Annotations) > 0 {
+				cmd.Println("Annotations:")
+				for i, annotation := range task.Annotations {
+					cmd.Printf("  %d. %s  %s\n", i+1, annotation.Entry.Format("2006-01-02 15:04"), annotation.Description)
+				}
+			}
+			if explainUrgency {
+				return explainTaskUrgency(cmd, task, lookup)
+			}
 
 			return nil
 		},
 	}
+	cobraCmd.Flags().BoolVar(&explainUrgency, "explain-urgency", false, "Break the task's urgency score down by factor")
+	cobraCmd.Flags().StringVarP(&output, "output", "o", outputTable, outputFlagUsage)
 	return cobraCmd
 }
+
+// explainTaskUrgency prints the factors that make up the task's urgency.
+func explainTaskUrgency(cmd *cobra.Command, task *model.Task, lookup model.TaskLookup) error {
+	coefficients := configOf(cmd).Urgency
+	now := time.Now()
+	terms := task.UrgencyTerms(coefficients, lookup, now)
+	cmd.Printf("\nUrgency: %s\n", formatUrgency(task.Urgency(coefficients, lookup, now)))
+	if len(terms) == 0 {
+		if task.IsClosed() {
+			cmd.Printf("Task %d is %s and has no urgency.\n", task.ID, task.State())
+		}
+		return nil
+	}
+
+	rows := make([][]string, len(terms))
+	for i, term := range terms {
+		rows[i] = []string{
+			term.Factor,
+			term.Detail,
+			strconv.FormatFloat(term.Value, 'f', 2, 64),
+			strconv.FormatFloat(term.Coefficient, 'f', -1, 64),
+			formatUrgency(term.Score()),
+		}
+	}
+	dm := NewDisplayManager(cmd.OutOrStdout())
+	return dm.renderTable([]string{"Factor", "Detail", "Value", "Coefficient", "Score"}, rows)
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kevin7254/task/model"
+)
+
+// sortField compares tasks by one field. missing, if set, reports tasks that
+// have no value for the field; they always sort last, whatever the direction.
+type sortField struct {
+	compare    func(a, b *model.Task, env taskEnv) int
+	missing    func(t *model.Task) bool
+	descending bool
+}
+
+func compareTime(get func(t *model.Task) time.Time) func(a, b *model.Task, env taskEnv) int {
+	return func(a, b *model.Task, _ taskEnv) int { return get(a).Compare(get(b)) }
+}
+
+func compareText(get func(t *model.Task) string) func(a, b *model.Task, env taskEnv) int {
+	return func(a, b *model.Task, _ taskEnv) int {
+		return strings.Compare(strings.ToLower(get(a)), strings.ToLower(get(b)))
+	}
+}
+
+func isZeroTime(get func(t *model.Task) time.Time) func(t *model.Task) bool {
+	return func(t *model.Task) bool { return get(t).IsZero() }
+}
+
+func isEmpty(get func(t *model.Task) string) func(t *model.Task) bool {
+	return func(t *model.Task) bool { return get(t) == "" }
+}
+
+var (
+	dueDate     = func(t *model.Task) time.Time { return t.DueDate }
+	createdAt   = func(t *model.Task) time.Time { return t.CreatedAt }
+	completedAt = func(t *model.Task) time.Time { return t.CompletedAt }
+	project     = func(t *model.Task) string { return t.Project }
+	description = func(t *model.Task) string { return t.Description }
+	recurRule   = func(t *model.Task) string {
+		if t.Recur == nil {
+			return ""
+		}
+		return t.Recur.String()
+	}
+)
+
+// sortFields lists the keys accepted by --sort. descending is the direction
+// used when a key is given without "+" or "-".
+var sortFields = map[string]sortField{
+	"id":          {compare: func(a, b *model.Task, _ taskEnv) int { return cmp.Compare(a.ID, b.ID) }},
+	"title":       {compare: compareText(func(t *model.Task) string { return t.Title })},
+	"description": {compare: compareText(description), missing: isEmpty(description)},
+	"project":     {compare: compareText(project), missing: isEmpty(project)},
+	"priority":    {compare: func(a, b *model.Task, _ taskEnv) int { return cmp.Compare(a.Priority, b.Priority) }, descending: true},
+	"due":         {compare: compareTime(dueDate), missing: isZeroTime(dueDate)},
+	"created":     {compare: compareTime(createdAt), missing: isZeroTime(createdAt)},
+	"completed":   {compare: compareTime(completedAt), missing: isZeroTime(completedAt)},
+	"age":         {compare: compareTime(createdAt), missing: isZeroTime(createdAt)},
+	"time_spent":  {compare: func(a, b *model.Task, _ taskEnv) int { return cmp.Compare(a.TimeSpent(), b.TimeSpent()) }, descending: true},
+	"tags":        {compare: compareText(func(t *model.Task) string { return strings.Join(t.Tags, " ") }), missing: func(t *model.Task) bool { return len(t.Tags) == 0 }},
+	"parent":      {compare: func(a, b *model.Task, _ taskEnv) int { return cmp.Compare(a.ParentID, b.ParentID) }, missing: func(t *model.Task) bool { return t.ParentID == 0 }},
+	"recur":       {compare: compareText(recurRule), missing: isEmpty(recurRule)},
+	"status":      {compare: func(a, b *model.Task, _ taskEnv) int { return cmp.Compare(statusRank(a), statusRank(b)) }},
+	"urgency":     {compare: func(a, b *model.Task, env taskEnv) int { return cmp.Compare(env.urgencyOf(a), env.urgencyOf(b)) }, descending: true},
+}
+
+// statusRank orders tasks by their status in workflow order.
+func statusRank(t *model.Task) int {
+	return slices.Index(model.Statuses, t.State())
+}
+
+// sortKey is one key of a sort order such as "priority-,due+,project".
+type sortKey struct {
+	name       string
+	descending bool
+}
+
+// parseSortKeys parses a comma-separated sort order. A trailing "+" sorts a
+// key ascending and "-" descending; without either, the key's natural
+// direction is used (highest priority, urgency and most time spent first,
+// everything else ascending).
+func parseSortKeys(spec string) ([]sortKey, error) {
+	var keys []sortKey
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		name := strings.TrimRight(part, "+-")
+		field, ok := sortFields[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown sort key %q (available: %s)", name, strings.Join(sortFieldNames(), ", "))
+		}
+		key := sortKey{name: name, descending: field.descending}
+		switch strings.TrimPrefix(part, name) {
+		case "+":
+			key.descending = false
+		case "-":
+			key.descending = true
+		case "":
+		default:
+			return nil, fmt.Errorf("invalid sort key %q: use %s+ or %s-", part, name, name)
+		}
+		// "age" grows as "created" shrinks, so its ascending order is newest first.
+		if name == "age" {
+			key.descending = !key.descending
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// sortFieldNames returns the names of all sort keys, sorted.
+func sortFieldNames() []string {
+	names := make([]string, 0, len(sortFields))
+	for name := range sortFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortTasks sorts tasks in place by keys. The sort is stable, with the task ID
+// as the final tie-breaker, so equal tasks keep a fixed order between runs.
+// Tasks without a value for a key sort after those with one.
+func sortTasks(tasks []*model.Task, keys []sortKey, env taskEnv) {
+	slices.SortStableFunc(tasks, func(a, b *model.Task) int {
+		for _, key := range keys {
+			field := sortFields[key.name]
+			if field.missing != nil {
+				aMissing, bMissing := field.missing(a), field.missing(b)
+				switch {
+				case aMissing && bMissing:
+					continue
+				case aMissing:
+					return 1
+				case bMissing:
+					return -1
+				}
+			}
+			c := field.compare(a, b, env)
+			if key.descending {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+}
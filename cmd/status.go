@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kevin7254/task/model"
+	"github.com/kevin7254/task/store"
+	"github.com/spf13/cobra"
+)
+
+// NewCancelCmd creates the 'cancel' command, which closes tasks that will
+// not be done.
+func NewCancelCmd(taskStore store.TaskRepository) *cobra.Command {
+	var yes bool
+	cobraCmd := &cobra.Command{
+		Use:   "cancel ID [ID...] | FILTER",
+		Short: "Cancel task(s) that will not be done",
+		Long: `Cancel one or more tasks. Cancelled tasks are kept, unlike removed ones,
+but are hidden from "task list" like done tasks and no longer block the
+tasks that depend on them. Cancelling a recurring task ends its series.
+"task edit ID --reopen" makes a cancelled task pending again.
+
+Examples:
+  task cancel 4             # Cancel task 4
+  task cancel project:old   # Cancel every open task in the 'old' project`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return changeStatus(cmd, taskStore, args, yes, model.StatusCancelled, "Cancel", "Cancelled")
+		},
+	}
+	cobraCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Don't ask for confirmation when a filter matches many tasks")
+	return acceptFilterArgs(taskStore, cobraCmd)
+}
+
+// NewWaitCmd creates the 'wait' command, which parks tasks that wait on
+// something outside the task list.
+func NewWaitCmd(taskStore store.TaskRepository) *cobra.Command {
+	var yes bool
+	cobraCmd := &cobra.Command{
+		Use:   "wait ID [ID...] | FILTER",
+		Short: "Mark task(s) as waiting",
+		Long: `Mark one or more tasks as waiting on something outside the task list,
+such as a reply. Waiting tasks stay in "task list" but are left out of
+"task next". "task start" or "task edit ID --status pending" picks them up
+again.
+
+Examples:
+  task wait 4          # Task 4 waits for a reply
+  task wait +review    # Every open task tagged review`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return changeStatus(cmd, taskStore, args, yes, model.StatusWaiting, "Mark as waiting", "Waiting")
+		},
+	}
+	cobraCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Don't ask for confirmation when a filter matches many tasks")
+	return acceptFilterArgs(taskStore, cobraCmd)
+}
+
+// changeStatus moves the tasks selected by args to status. verb is used in
+// the confirmation question and done in the report of each changed task.
+func changeStatus(cmd *cobra.Command, taskStore store.TaskRepository, args []string, yes bool, status model.Status, verb string, done string) error {
+	tasks, selectErr := selectTasks(cmd, taskStore, args, yes, verb)
+	if selectErr != nil {
+		return selectErr
+	}
+
+	now := time.Now()
+	for _, task := range tasks {
+		if err := task.SetStatus(status, now); err != nil {
+			return fmt.Errorf("task %d: %w", task.ID, err)
+		}
+		if err := taskStore.UpdateTask(task); err != nil {
+			return fmt.Errorf("failed to update task %d: %w", task.ID, err)
+		}
+		cmd.Printf("%s task %d: %s\n", done, task.ID, task.Title)
+	}
+	return nil
+}
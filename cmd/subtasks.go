@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/kevin7254/task/model"
+	"github.com/kevin7254/task/store"
+)
+
+// childrenOf returns the direct subtasks of parentID, preserving the order of tasks.
+func childrenOf(tasks []*model.Task, parentID int) []*model.Task {
+	var children []*model.Task
+	for _, task := range tasks {
+		if task.ParentID == parentID && task.ID != parentID {
+			children = append(children, task)
+		}
+	}
+	return children
+}
+
+// openDescendantsOf returns every incomplete subtask below parentID, at any depth.
+func openDescendantsOf(tasks []*model.Task, parentID int) []*model.Task {
+	var open []*model.Task
+	visited := map[int]bool{parentID: true}
+	queue := []int{parentID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, child := range childrenOf(tasks, id) {
+			if visited[child.ID] {
+				continue
+			}
+			visited[child.ID] = true
+			queue = append(queue, child.ID)
+			if !child.IsClosed() {
+				open = append(open, child)
+			}
+		}
+	}
+	return open
+}
+
+// subtaskProgress counts the completed and total direct subtasks of parentID.
+func subtaskProgress(tasks []*model.Task, parentID int) (done int, total int) {
+	for _, child := range childrenOf(tasks, parentID) {
+		total++
+		if child.IsClosed() {
+			done++
+		}
+	}
+	return done, total
+}
+
+// detachSubtasksOf makes the direct subtasks of parentID top-level tasks, so
+// that none is left pointing at a removed parent, and returns the tasks that
+// were changed.
+func detachSubtasksOf(taskStore store.TaskRepository, parentID int) ([]*model.Task, error) {
+	var changed []*model.Task
+	for _, child := range childrenOf(taskStore.ListAllTasks(), parentID) {
+		child.ParentID = 0
+		if err := taskStore.UpdateTask(child); err != nil {
+			return changed, fmt.Errorf("failed to update task %d: %w", child.ID, err)
+		}
+		changed = append(changed, child)
+	}
+	return changed, nil
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kevin7254/task/store"
+	"github.com/spf13/cobra"
+)
+
+// NewTagsCmd creates the 'tags' command, which lists tags and how many tasks use them.
+func NewTagsCmd(taskStore store.TaskRepository) *cobra.Command {
+	var showCompleted bool
+	cobraCmd := &cobra.Command{
+		Use:   "tags",
+		Short: "List tags with task counts",
+		Long: `List all tags in use together with the number of tasks carrying them.
+
+Examples:
+  task tags     # Tags on incomplete tasks
+  task tags -c  # Include completed tasks in the counts`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			counts := make(map[string]int)
+			for _, task := range taskStore.ListAllTasks() {
+				if !showCompleted && task.IsClosed() {
+					continue
+				}
+				for _, tag := range task.Tags {
+					counts[tag]++
+				}
+			}
+
+			if len(counts) == 0 {
+				cmd.Println("No tags found.")
+				return nil
+			}
+
+			tags := make([]string, 0, len(counts))
+			for tag := range counts {
+				tags = append(tags, tag)
+			}
+			sort.Slice(tags, func(i, j int) bool {
+				if counts[tags[i]] != counts[tags[j]] {
+					return counts[tags[i]] > counts[tags[j]]
+				}
+				return tags[i] < tags[j]
+			})
+
+			rows := make([][]string, len(tags))
+			for i, tag := range tags {
+				rows[i] = []string{"+" + tag, strconv.Itoa(counts[tag])}
+			}
+			dm := NewDisplayManager(cmd.OutOrStdout())
+			return dm.renderTable([]string{"Tag", "Count"}, rows)
+		},
+	}
+	cobraCmd.Flags().BoolVarP(&showCompleted, "completed", "c", false, "Include completed tasks in the counts")
+	return cobraCmd
+}
+
+// splitTagArgs separates "+tag" arguments from the other words on a command line.
+func splitTagArgs(args []string) (words []string, tags []string) {
+	for _, arg := range args {
+		if len(arg) > 1 && strings.HasPrefix(arg, "+") && !strings.ContainsAny(arg, " \t") {
+			tags = append(tags, arg)
+			continue
+		}
+		words = append(words, arg)
+	}
+	return words, tags
+}
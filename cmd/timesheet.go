@@ -0,0 +1,374 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kevin7254/task/dateparse"
+	"github.com/kevin7254/task/model"
+	"github.com/kevin7254/task/store"
+	"github.com/spf13/cobra"
+)
+
+// timesheetGrouping is a way of grouping tracked time in a timesheet.
+type timesheetGrouping struct {
+	header string
+	// key returns the label of the group that time spent on task on day
+	// belongs to, and a string that sorts the groups in order.
+	key func(task *model.Task, day time.Time) (label string, order string)
+}
+
+// timesheetGroupings lists the values accepted by --by.
+var timesheetGroupings = map[string]timesheetGrouping{
+	"day": {"Day", func(_ *model.Task, day time.Time) (string, string) {
+		return day.Format("2006-01-02 Mon"), day.Format("2006-01-02")
+	}},
+	"week": {"Week", func(_ *model.Task, day time.Time) (string, string) {
+		year, week := day.ISOWeek()
+		label := fmt.Sprintf("%d-W%02d", year, week)
+		return label, label
+	}},
+	"month": {"Month", func(_ *model.Task, day time.Time) (string, string) {
+		return day.Format("2006-01"), day.Format("2006-01")
+	}},
+	"project": {"Project", func(task *model.Task, _ time.Time) (string, string) {
+		if task.Project == "" {
+			// Sorts after every named project.
+			return "(none)", "\xff"
+		}
+		return task.Project, strings.ToLower(task.Project)
+	}},
+	"task": {"Task", func(task *model.Task, _ time.Time) (string, string) {
+		return fmt.Sprintf("%d %s", task.ID, task.Title), fmt.Sprintf("%010d", task.ID)
+	}},
+}
+
+// timesheetNode accumulates the time of one group and its subgroups.
+type timesheetNode struct {
+	label    string
+	order    string
+	total    time.Duration
+	children map[string]*timesheetNode
+}
+
+// add records d in the group path given by keys, creating groups as needed.
+func (n *timesheetNode) add(keys [][2]string, d time.Duration) {
+	n.total += d
+	if len(keys) == 0 {
+		return
+	}
+	if n.children == nil {
+		n.children = make(map[string]*timesheetNode)
+	}
+	label, order := keys[0][0], keys[0][1]
+	child, ok := n.children[order+"\x00"+label]
+	if !ok {
+		child = &timesheetNode{label: label, order: order}
+		n.children[order+"\x00"+label] = child
+	}
+	child.add(keys[1:], d)
+}
+
+// sortedChildren returns the subgroups in order. Groups with less than half
+// a minute, such as a task started and stopped again right away, are left
+// out rather than shown as 0m.
+func (n *timesheetNode) sortedChildren() []*timesheetNode {
+	children := make([]*timesheetNode, 0, len(n.children))
+	for _, child := range n.children {
+		if roundMinutes(child.total) > 0 {
+			children = append(children, child)
+		}
+	}
+	slices.SortFunc(children, func(a, b *timesheetNode) int {
+		if c := strings.Compare(a.order, b.order); c != 0 {
+			return c
+		}
+		return strings.Compare(a.label, b.label)
+	})
+	return children
+}
+
+// NewTimesheetCmd creates the 'timesheet' command, which reports tracked time.
+func NewTimesheetCmd(taskStore store.TaskRepository) *cobra.Command {
+	var (
+		by     []string
+		from   string
+		to     string
+		output string
+	)
+	cobraCmd := &cobra.Command{
+		Use:   "timesheet [FILTER]",
+		Short: "Report the time tracked on tasks",
+		Long: `Report the time tracked on tasks, grouped by day, week, month, project or
+task. Several groupings nest, with a subtotal for each group, and the report
+ends with the total. Time is split at midnight, so an interval that runs past
+midnight counts towards both days (and both weeks, at the end of a week).
+Groups with less than half a minute are left out.
+
+A filter (see "task list --help") selects the tasks, including completed ones.
+--from and --to limit the report to a date range; a date without a time of
+day includes that whole day. A report for a date range includes the time of
+archived tasks.
+
+With --output csv there is one row per innermost group, without subtotals,
+ready for a spreadsheet. With --output json the groups nest and carry their
+totals.
+
+Examples:
+  task timesheet                                # Time per day
+  task timesheet --by week,project --from 2025-06-01 --to 2025-06-30
+  task timesheet project:acme --by task -o csv  # Invoice lines for a client`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			groupings := make([]timesheetGrouping, 0, len(by))
+			names := make([]string, 0, len(by))
+			for _, name := range by {
+				name = strings.ToLower(strings.TrimSpace(name))
+				grouping, ok := timesheetGroupings[name]
+				if !ok {
+					return fmt.Errorf("unknown grouping %q (expected day, week, month, project or task)", name)
+				}
+				groupings = append(groupings, grouping)
+				names = append(names, name)
+			}
+			if len(groupings) == 0 {
+				return fmt.Errorf("--by needs at least one grouping")
+			}
+
+			now := time.Now()
+			start, startErr := parseRangeFlag(from, now, false)
+			if startErr != nil {
+				return fmt.Errorf("invalid --from: %w", startErr)
+			}
+			end, endErr := parseRangeFlag(to, now, true)
+			if endErr != nil {
+				return fmt.Errorf("invalid --to: %w", endErr)
+			}
+			if !start.IsZero() && !end.IsZero() && !start.Before(end) {
+				return fmt.Errorf("--from must be before --to")
+			}
+
+			if output != outputTable && output != outputCSV && output != outputJSON {
+				return fmt.Errorf("unknown output format %q (expected table, csv or json)", output)
+			}
+
+			tasks := taskStore.ListAllTasks()
+			lookup := taskStore.GetTaskByID
+			// The time of a range is only complete with the tasks that have
+			// been archived since.
+			if (!start.IsZero() || !end.IsZero()) && hasArchive(cmd) {
+				archive, err := archiveOf(cmd)
+				if err != nil {
+					return err
+				}
+				tasks = append(tasks, archive.ListAllTasks()...)
+				lookup = lookupWithArchive(taskStore, archive)
+			}
+			if len(args) > 0 {
+				expr, err := parseQuery(args)
+				if err != nil {
+					return err
+				}
+				tasks = filterTasks(tasks, &listOptions{filter: expr, showCompleted: true}, lookup)
+			}
+
+			root := &timesheetNode{}
+			for _, task := range tasks {
+				for _, segment := range task.TimeSegments(start, end, now) {
+					keys := make([][2]string, len(groupings))
+					for i, grouping := range groupings {
+						label, order := grouping.key(task, segment.Start)
+						keys[i] = [2]string{label, order}
+					}
+					root.add(keys, segment.End.Sub(segment.Start))
+				}
+			}
+
+			w := cmd.OutOrStdout()
+			switch output {
+			case outputCSV:
+				return writeTimesheetCSV(w, root, groupings)
+			case outputJSON:
+				return writeTimesheetJSON(w, root, names, start, end)
+			}
+			if roundMinutes(root.total) == 0 {
+				cmd.Println("No time tracked.")
+				return nil
+			}
+			return writeTimesheetTable(w, root, groupings)
+		},
+	}
+	cobraCmd.Flags().StringSliceVar(&by, "by", []string{"day"}, "Group by day, week, month, project or task; several nest, e.g. week,project")
+	cobraCmd.Flags().StringVar(&from, "from", "", "Only count time from this date on, e.g. 2025-06-01 or yesterday")
+	cobraCmd.Flags().StringVar(&to, "to", "", "Only count time up to this date, inclusive, e.g. 2025-06-30 or today")
+	cobraCmd.Flags().StringVarP(&output, "output", "o", outputTable, "Output format: table, csv or json")
+	return acceptFilterArgs(taskStore, cobraCmd)
+}
+
+// parseRangeFlag parses a --from or --to date. For the end of a range, a
+// date without a time of day means the end of that day.
+func parseRangeFlag(value string, now time.Time, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := dateparse.Parse(value, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end && dateparse.IsDateOnly(t) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// writeTimesheetTable prints one row per group with subtotals after every
+// group that has subgroups, followed by the total.
+func writeTimesheetTable(w io.Writer, root *timesheetNode, groupings []timesheetGrouping) error {
+	headers := make([]string, 0, len(groupings)+1)
+	for _, grouping := range groupings {
+		headers = append(headers, grouping.header)
+	}
+	headers = append(headers, "Time")
+
+	var rows [][]string
+	var walk func(node *timesheetNode, path []string)
+	walk = func(node *timesheetNode, path []string) {
+		for _, child := range node.sortedChildren() {
+			childPath := append(slices.Clone(path), child.label)
+			if len(child.sortedChildren()) == 0 {
+				rows = append(rows, timesheetRow(childPath, len(groupings), child.total))
+				continue
+			}
+			walk(child, childPath)
+			subtotal := append(slices.Clone(path), child.label+" subtotal")
+			rows = append(rows, timesheetRow(subtotal, len(groupings), child.total))
+		}
+	}
+	walk(root, nil)
+	rows = append(rows, timesheetRow([]string{"Total"}, len(groupings), root.total))
+
+	dm := NewDisplayManager(w)
+	return dm.renderTable(headers, rows)
+}
+
+// timesheetRow pads labels to width columns and appends the time.
+func timesheetRow(labels []string, width int, d time.Duration) []string {
+	row := make([]string, width, width+1)
+	copy(row, labels)
+	return append(row, formatDuration(d))
+}
+
+// writeTimesheetCSV writes one row per innermost group with its time in
+// minutes and in hours.
+func writeTimesheetCSV(w io.Writer, root *timesheetNode, groupings []timesheetGrouping) error {
+	writer := csv.NewWriter(w)
+	header := make([]string, 0, len(groupings)+2)
+	for _, grouping := range groupings {
+		header = append(header, strings.ToLower(grouping.header))
+	}
+	if err := writer.Write(append(header, "minutes", "hours")); err != nil {
+		return err
+	}
+
+	var walk func(node *timesheetNode, path []string) error
+	walk = func(node *timesheetNode, path []string) error {
+		children := node.sortedChildren()
+		if len(children) == 0 {
+			if len(path) == 0 {
+				return nil
+			}
+			record := append(slices.Clone(path), strconv.FormatInt(roundMinutes(node.total), 10), formatHours(node.total))
+			return writer.Write(record)
+		}
+		for _, child := range children {
+			if err := walk(child, append(slices.Clone(path), child.label)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(root, nil); err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// timesheetJSON is the --output json form of a timesheet.
+type timesheetJSON struct {
+	From    *time.Time           `json:"from"`
+	To      *time.Time           `json:"to"`
+	GroupBy []string             `json:"group_by"`
+	Minutes int64                `json:"minutes"`
+	Hours   float64              `json:"hours"`
+	Groups  []timesheetGroupJSON `json:"groups"`
+}
+
+// timesheetGroupJSON is one group of a timesheet with its subgroups.
+type timesheetGroupJSON struct {
+	Key     string               `json:"key"`
+	Minutes int64                `json:"minutes"`
+	Hours   float64              `json:"hours"`
+	Groups  []timesheetGroupJSON `json:"groups,omitempty"`
+}
+
+// writeTimesheetJSON writes the timesheet as nested groups with totals.
+func writeTimesheetJSON(w io.Writer, root *timesheetNode, groupBy []string, from, to time.Time) error {
+	var convert func(node *timesheetNode) []timesheetGroupJSON
+	convert = func(node *timesheetNode) []timesheetGroupJSON {
+		children := node.sortedChildren()
+		if len(children) == 0 {
+			return nil
+		}
+		groups := make([]timesheetGroupJSON, len(children))
+		for i, child := range children {
+			groups[i] = timesheetGroupJSON{
+				Key:     child.label,
+				Minutes: roundMinutes(child.total),
+				Hours:   roundHours(child.total),
+				Groups:  convert(child),
+			}
+		}
+		return groups
+	}
+
+	sheet := timesheetJSON{
+		GroupBy: groupBy,
+		Minutes: roundMinutes(root.total),
+		Hours:   roundHours(root.total),
+		Groups:  convert(root),
+	}
+	if sheet.Groups == nil {
+		sheet.Groups = []timesheetGroupJSON{}
+	}
+	if !from.IsZero() {
+		sheet.From = &from
+	}
+	if !to.IsZero() {
+		sheet.To = &to
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sheet)
+}
+
+// roundMinutes returns d in minutes, rounded to the nearest minute.
+func roundMinutes(d time.Duration) int64 {
+	return int64(d.Round(time.Minute) / time.Minute)
+}
+
+// roundHours returns d in hours, rounded to two decimals as used on invoices.
+func roundHours(d time.Duration) float64 {
+	return math.Round(d.Hours()*100) / 100
+}
+
+// formatHours renders d in hours with two decimals.
+func formatHours(d time.Duration) string {
+	return strconv.FormatFloat(roundHours(d), 'f', 2, 64)
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kevin7254/task/dateparse"
+	"github.com/kevin7254/task/model"
+	"github.com/kevin7254/task/store"
+	"github.com/spf13/cobra"
+)
+
+// NewStartCmd creates the 'start' command, which starts tracking time on a task.
+func NewStartCmd(taskStore store.TaskRepository) *cobra.Command {
+	return &cobra.Command{
+		Use:   "start ID",
+		Short: "Start tracking time on a task",
+		Long: `Start tracking time on a task and mark it in progress. Only one task is
+active at a time, so starting a task stops the one that was active before.
+The active task is marked with ▶ in "task list". Stopping it leaves it in
+progress.
+
+Examples:
+  task start 3  # Start working on task 3
+  task stop     # Stop working on it`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			task, resolveErr := resolveTask(taskStore, args[0])
+			if resolveErr != nil {
+				return resolveErr
+			}
+			id := task.ID
+			if task.IsClosed() {
+				return fmt.Errorf("task %d is %s", id, task.State())
+			}
+			if task.IsActive() {
+				return fmt.Errorf("task %d is already started", id)
+			}
+
+			now := time.Now()
+			for _, active := range activeTasks(taskStore.ListAllTasks()) {
+				if err := stopTask(cmd, taskStore, active, now); err != nil {
+					return err
+				}
+			}
+
+			if err := task.Start(now); err != nil {
+				return fmt.Errorf("failed to start task %d: %w", id, err)
+			}
+			if task.State() != model.StatusInProgress {
+				if err := task.SetStatus(model.StatusInProgress, now); err != nil {
+					return fmt.Errorf("failed to start task %d: %w", id, err)
+				}
+			}
+			if err := taskStore.UpdateTask(task); err != nil {
+				return fmt.Errorf("failed to update task %d: %w", id, err)
+			}
+			cmd.Printf("Started task %d: %s\n", id, task.Title)
+			return nil
+		},
+	}
+}
+
+// NewStopCmd creates the 'stop' command, which stops tracking time.
+func NewStopCmd(taskStore store.TaskRepository) *cobra.Command {
+	return &cobra.Command{
+		Use:   "stop [ID]",
+		Short: "Stop tracking time on the active task",
+		Long: `Stop tracking time on the active task, or on the given task.
+
+Examples:
+  task stop    # Stop the active task
+  task stop 3  # Stop task 3`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var active []*model.Task
+			if len(args) == 1 {
+				task, resolveErr := resolveTask(taskStore, args[0])
+				if resolveErr != nil {
+					return resolveErr
+				}
+				id := task.ID
+				if !task.IsActive() {
+					return fmt.Errorf("task %d is not started", id)
+				}
+				active = append(active, task)
+			} else {
+				active = activeTasks(taskStore.ListAllTasks())
+				if len(active) == 0 {
+					return fmt.Errorf("no task is started")
+				}
+			}
+
+			now := time.Now()
+			for _, task := range active {
+				if err := stopTask(cmd, taskStore, task, now); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// NewTrackCmd creates the 'track' command, which records time after the fact.
+func NewTrackCmd(taskStore store.TaskRepository) *cobra.Command {
+	var at string
+	cobraCmd := &cobra.Command{
+		Use:   "track ID DURATION",
+		Short: "Record time spent on a task",
+		Long: `Record time spent on a task without starting and stopping it. The
+duration is a number of minutes or a duration such as 45m or 1h30m. By default
+the time ends now; --at sets when it started instead. A date without a time of
+day starts at 9:00.
+
+Examples:
+  task track 3 45m                   # 45 minutes ending now
+  task track 3 1h30m --at yesterday  # 1.5 hours yesterday from 9:00
+  task track 3 20 --at "mon 14:00"   # 20 minutes on Monday from 14:00`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			task, resolveErr := resolveTask(taskStore, args[0])
+			if resolveErr != nil {
+				return resolveErr
+			}
+			id := task.ID
+			spent, durationErr := parseTrackedDuration(args[1])
+			if durationErr != nil {
+				return durationErr
+			}
+
+			now := time.Now()
+			start := now.Add(-spent)
+			if at != "" {
+				parsed, err := dateparse.Parse(at, now)
+				if err != nil {
+					return fmt.Errorf("invalid --at: %w", err)
+				}
+				if dateparse.IsDateOnly(parsed) {
+					parsed = parsed.Add(9 * time.Hour)
+				}
+				start = parsed
+			}
+			if start.Add(spent).After(now) {
+				return fmt.Errorf("cannot track time that ends in the future")
+			}
+
+			task.Track(start, spent)
+			if err := taskStore.UpdateTask(task); err != nil {
+				return fmt.Errorf("failed to update task %d: %w", id, err)
+			}
+			cmd.Printf("Tracked %s on task %d from %s: %s\n", formatDuration(spent), id, start.Format("2006-01-02 15:04"), task.Title)
+			return nil
+		},
+	}
+	cobraCmd.Flags().StringVar(&at, "at", "", "When the time started, e.g. yesterday, \"mon 14:00\" or 2025-06-03T09:30")
+	return cobraCmd
+}
+
+// activeTasks returns the tasks whose time is being tracked. Normally there is
+// at most one.
+func activeTasks(tasks []*model.Task) []*model.Task {
+	var active []*model.Task
+	for _, task := range tasks {
+		if task.IsActive() {
+			active = append(active, task)
+		}
+	}
+	return active
+}
+
+// stopTask ends the task's running time entry at now and saves it.
+func stopTask(cmd *cobra.Command, taskStore store.TaskRepository, task *model.Task, now time.Time) error {
+	spent, err := task.Stop(now)
+	if err != nil {
+		return fmt.Errorf("failed to stop task %d: %w", task.ID, err)
+	}
+	if err := taskStore.UpdateTask(task); err != nil {
+		return fmt.Errorf("failed to update task %d: %w", task.ID, err)
+	}
+	cmd.Printf("Stopped task %d after %s: %s\n", task.ID, formatDuration(spent), task.Title)
+	return nil
+}
+
+// parseTrackedDuration parses a number of minutes or a duration such as "1h30m".
+func parseTrackedDuration(value string) (time.Duration, error) {
+	text := strings.TrimSpace(value)
+	if minutes, err := strconv.Atoi(text); err == nil {
+		text = strconv.Itoa(minutes) + "m"
+	}
+	d, err := time.ParseDuration(text)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q: use minutes or a duration such as 45m or 1h30m", value)
+	}
+	return d, nil
+}
+
+// formatDuration renders a tracked duration rounded to the minute, such as
+// "1h5m" or "0m".
+func formatDuration(d time.Duration) string {
+	minutes := int64(d.Round(time.Minute) / time.Minute)
+	if minutes == 0 {
+		return "0m"
+	}
+	return formatMinutes(minutes)
+}
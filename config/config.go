@@ -0,0 +1,189 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kevin7254/task/model"
+)
+
+// FileName is the name of the configuration file inside the task directory.
+const FileName = "config.json"
+
+// Config holds the user's settings, read from ~/.task/config.json.
+type Config struct {
+	Store   StoreConfig             `json:"store"`
+	Bulk    BulkConfig              `json:"bulk"`
+	Archive ArchiveConfig           `json:"archive"`
+	Backup  BackupConfig            `json:"backup"`
+	Reports map[string]ReportConfig `json:"reports"`
+	// Urgency holds the coefficients of the urgency score shown by "task next".
+	Urgency model.UrgencyCoefficients `json:"urgency"`
+}
+
+// StoreConfig selects and configures the task storage backend.
+type StoreConfig struct {
+	// Backend is either "json" (default) or "sqlite".
+	Backend string `json:"backend"`
+	// Path is the file the backend persists to. Relative paths are resolved
+	// against the task directory.
+	Path string `json:"path"`
+	// LockTimeout is how long to wait for another task process to release the store.
+	LockTimeout Duration `json:"lock_timeout"`
+}
+
+// BulkConfig controls commands that act on every task matching a filter.
+type BulkConfig struct {
+	// ConfirmThreshold is the number of matching tasks above which a bulk
+	// command asks for confirmation before changing them.
+	ConfirmThreshold int `json:"confirm_threshold"`
+}
+
+// ArchiveConfig controls the archive that done and cancelled tasks are moved
+// to, so they no longer slow down every command.
+type ArchiveConfig struct {
+	// Path is the file the archive persists to, with the store's backend.
+	// Relative paths are resolved against the task directory. By default it
+	// is the store path with ".archive" before the extension.
+	Path string `json:"path"`
+	// AfterDays is the number of days after which done and cancelled tasks
+	// are archived automatically. Zero turns automatic archiving off.
+	AfterDays int `json:"after_days"`
+}
+
+// BackupConfig controls the snapshots of the store that are taken before
+// destructive commands.
+type BackupConfig struct {
+	// Dir is the directory snapshots are written to. Relative paths are
+	// resolved against the task directory.
+	Dir string `json:"dir"`
+	// Keep is the number of snapshots kept; older ones are removed when a
+	// new one is taken. Zero keeps every snapshot.
+	Keep int `json:"keep"`
+}
+
+// ReportConfig defines a saved report, run with "task report NAME".
+type ReportConfig struct {
+	// Description is shown by "task report" without arguments.
+	Description string `json:"description"`
+	// Columns to show, optionally with a maximum width such as "title:40".
+	Columns []string `json:"columns"`
+	// Filter selects the tasks, in the syntax of "task list".
+	Filter string `json:"filter"`
+	// Sort is the sort order, in the syntax of "task list --sort".
+	Sort string `json:"sort"`
+	// Limit is the maximum number of tasks shown; zero means no limit.
+	Limit int `json:"limit"`
+}
+
+// Duration is a time.Duration that is written as a string such as "5s" in JSON.
+type Duration struct {
+	time.Duration
+}
+
+// MarshalJSON encodes the duration in time.Duration string form.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON decodes a duration from a string such as "1m30s".
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if jsonErr := json.Unmarshal(b, &s); jsonErr != nil {
+		return fmt.Errorf("duration must be a string such as \"5s\": %w", jsonErr)
+	}
+	parsed, parseErr := time.ParseDuration(s)
+	if parseErr != nil {
+		return fmt.Errorf("invalid duration %q: %w", s, parseErr)
+	}
+	d.Duration = parsed
+	return nil
+}
+
+// Default returns the configuration used when no config file exists, with
+// all paths inside dir.
+func Default(dir string) *Config {
+	return &Config{
+		Store: StoreConfig{
+			Backend:     "json",
+			Path:        filepath.Join(dir, "tasks.json"),
+			LockTimeout: Duration{5 * time.Second},
+		},
+		Bulk: BulkConfig{
+			ConfirmThreshold: 3,
+		},
+		Archive: ArchiveConfig{
+			Path: filepath.Join(dir, "tasks.archive.json"),
+		},
+		Backup: BackupConfig{
+			Dir:  filepath.Join(dir, "backups"),
+			Keep: 20,
+		},
+		Reports: map[string]ReportConfig{
+			"next": {
+				Description: "Pending tasks that can be started now, most urgent first",
+				Columns:     []string{"id", "urgency", "priority", "due", "age", "project", "tags", "title:60"},
+				Filter:      "status:ready",
+				Sort:        "urgency",
+				Limit:       10,
+			},
+		},
+		Urgency: model.DefaultUrgencyCoefficients(),
+	}
+}
+
+// Load reads the configuration file from dir, falling back to the defaults
+// for anything the file does not set.
+func Load(dir string) (*Config, error) {
+	cfg := Default(dir)
+
+	bytes, osErr := os.ReadFile(filepath.Join(dir, FileName))
+	if osErr != nil {
+		if errors.Is(osErr, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to read config: %w", osErr)
+	}
+
+	// Clear the default path so a file that only switches the backend gets
+	// that backend's default file name.
+	defaultPath := cfg.Store.Path
+	cfg.Store.Path = ""
+	cfg.Archive.Path = ""
+	if unMarshalErr := json.Unmarshal(bytes, cfg); unMarshalErr != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", unMarshalErr)
+	}
+
+	switch {
+	case cfg.Store.Path == "" && cfg.Store.Backend == "sqlite":
+		cfg.Store.Path = filepath.Join(dir, "tasks.db")
+	case cfg.Store.Path == "":
+		cfg.Store.Path = defaultPath
+	case !filepath.IsAbs(cfg.Store.Path):
+		cfg.Store.Path = filepath.Join(dir, cfg.Store.Path)
+	}
+
+	switch {
+	case cfg.Archive.Path == "":
+		ext := filepath.Ext(cfg.Store.Path)
+		cfg.Archive.Path = strings.TrimSuffix(cfg.Store.Path, ext) + ".archive" + ext
+	case !filepath.IsAbs(cfg.Archive.Path):
+		cfg.Archive.Path = filepath.Join(dir, cfg.Archive.Path)
+	}
+	if !filepath.IsAbs(cfg.Backup.Dir) {
+		cfg.Backup.Dir = filepath.Join(dir, cfg.Backup.Dir)
+	}
+	if cfg.Archive.AfterDays < 0 {
+		return nil, fmt.Errorf("archive.after_days cannot be negative")
+	}
+	if cfg.Backup.Keep < 0 {
+		return nil, fmt.Errorf("backup.keep cannot be negative")
+	}
+
+	return cfg, nil
+}
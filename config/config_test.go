@@ -0,0 +1,126 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoad_Defaults(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Store.Backend != "json" {
+		t.Errorf("Expected json backend by default, got %q", cfg.Store.Backend)
+	}
+	if want := filepath.Join(dir, "tasks.json"); cfg.Store.Path != want {
+		t.Errorf("Expected default path %q, got %q", want, cfg.Store.Path)
+	}
+	if cfg.Bulk.ConfirmThreshold != 3 {
+		t.Errorf("Expected bulk commands to confirm above 3 tasks, got %d", cfg.Bulk.ConfirmThreshold)
+	}
+	if want := filepath.Join(dir, "backups"); cfg.Backup.Dir != want {
+		t.Errorf("Expected default backup directory %q, got %q", want, cfg.Backup.Dir)
+	}
+	if cfg.Backup.Keep != 20 {
+		t.Errorf("Expected 20 snapshots to be kept by default, got %d", cfg.Backup.Keep)
+	}
+}
+
+func TestLoad_SQLiteBackend(t *testing.T) {
+	dir := t.TempDir()
+	data := `{"store": {"backend": "sqlite", "lock_timeout": "250ms"}}`
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if want := filepath.Join(dir, "tasks.db"); cfg.Store.Path != want {
+		t.Errorf("Expected sqlite default path %q, got %q", want, cfg.Store.Path)
+	}
+	if cfg.Store.LockTimeout.Duration != 250*time.Millisecond {
+		t.Errorf("Expected lock timeout of 250ms, got %s", cfg.Store.LockTimeout)
+	}
+}
+
+func TestLoad_Reports(t *testing.T) {
+	dir := t.TempDir()
+	data := `{"reports": {"standup": {"columns": ["id", "title:30"], "filter": "project:work", "limit": 5}}}`
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	standup, ok := cfg.Reports["standup"]
+	if !ok || standup.Filter != "project:work" || standup.Limit != 5 || len(standup.Columns) != 2 {
+		t.Errorf("Expected the standup report from the config file, got %+v", cfg.Reports)
+	}
+	if _, ok := cfg.Reports["next"]; !ok {
+		t.Error("Expected the built-in next report to be kept")
+	}
+}
+
+func TestLoad_Urgency(t *testing.T) {
+	dir := t.TempDir()
+	data := `{"urgency": {"due": 20, "tag": {"+Urgent": 5}}}`
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Urgency.Due != 20 {
+		t.Errorf("Expected the due coefficient from the config file, got %v", cfg.Urgency.Due)
+	}
+	if cfg.Urgency.Priority != 6 {
+		t.Errorf("Expected the default priority coefficient to be kept, got %v", cfg.Urgency.Priority)
+	}
+	if cfg.Urgency.Tag["+Urgent"] != 5 || cfg.Urgency.Tag["next"] != 15 {
+		t.Errorf("Expected tag coefficients to be merged with the defaults, got %v", cfg.Urgency.Tag)
+	}
+}
+
+func TestLoad_ArchivePath(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if want := filepath.Join(dir, "tasks.archive.json"); cfg.Archive.Path != want {
+		t.Errorf("Expected default archive path %q, got %q", want, cfg.Archive.Path)
+	}
+
+	data := `{"store": {"backend": "sqlite"}, "archive": {"after_days": 30}}`
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	cfg, err = Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if want := filepath.Join(dir, "tasks.archive.db"); cfg.Archive.Path != want {
+		t.Errorf("Expected the archive next to the sqlite store at %q, got %q", want, cfg.Archive.Path)
+	}
+	if cfg.Archive.AfterDays != 30 {
+		t.Errorf("Expected tasks to be archived after 30 days, got %d", cfg.Archive.AfterDays)
+	}
+
+	data = `{"archive": {"path": "old.json", "after_days": -1}}`
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := Load(dir); err == nil {
+		t.Error("Expected a negative archive.after_days to be rejected")
+	}
+}
@@ -0,0 +1,251 @@
+// Package dateparse turns the date expressions accepted on the command line,
+// such as "tomorrow", "next monday", "in 3 days" or "2025-06-03 14:00", into
+// times.
+package dateparse
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// None is the expression that explicitly means "no date".
+const None = "none"
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// isoLayouts are the absolute formats accepted, most specific first.
+var isoLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// Parse interprets s relative to now, in now's location. Expressions that
+// name a day without a time of day resolve to midnight at the start of that
+// day. Supported expressions:
+//
+//	today, tomorrow, yesterday, now
+//	mon ... sun, monday ... sunday   the next such day after today
+//	next monday                      that day in the following week
+//	in 3 days, in 2 weeks, in 1 month, in 4 hours
+//	3d, 2w, 1m, 1y, 4h               short forms of the above
+//	eod, eow, eom, eoy               end of day, week (Sunday), month, year
+//	2025-06-03, 2025-06-03T14:00, 2025-06-03 14:00, RFC 3339
+//
+// A day expression may be followed by a time of day: "tomorrow 14:00",
+// "fri 9am", "next monday 5:30pm".
+func Parse(s string, now time.Time) (time.Time, error) {
+	expr := strings.ToLower(strings.TrimSpace(s))
+	if expr == "" {
+		return time.Time{}, fmt.Errorf("empty date")
+	}
+
+	for _, layout := range isoLayouts {
+		if t, err := time.ParseInLocation(layout, strings.ToUpper(expr), now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	fields := strings.Fields(expr)
+	// A trailing time of day applies to whatever day the rest names.
+	if len(fields) > 1 {
+		if hour, minute, ok := parseClock(fields[len(fields)-1]); ok {
+			day, err := parseDay(strings.Join(fields[:len(fields)-1], " "), now)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, now.Location()), nil
+		}
+	}
+	return parseDay(expr, now)
+}
+
+// IsNone reports whether s explicitly asks for no date.
+func IsNone(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case None, "never", "no":
+		return true
+	}
+	return false
+}
+
+// Format renders t as a date, adding the time of day when it isn't midnight.
+// The zero time renders as an empty string.
+func Format(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	if IsDateOnly(t) {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+// IsDateOnly reports whether t is at midnight, which is how dates without a
+// time of day are stored.
+func IsDateOnly(t time.Time) bool {
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+}
+
+// parseDay resolves relative expressions that do not carry a time of day.
+func parseDay(expr string, now time.Time) (time.Time, error) {
+	today := startOfDay(now)
+
+	switch expr {
+	case "now":
+		return now, nil
+	case "today", "sod":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "eod":
+		return today, nil
+	case "eow":
+		return today.AddDate(0, 0, 6-mondayIndex(now.Weekday())), nil
+	case "eom":
+		return time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, now.Location()), nil
+	case "eoy":
+		return time.Date(now.Year(), time.December, 31, 0, 0, 0, 0, now.Location()), nil
+	}
+
+	if day, ok := weekdays[expr]; ok {
+		return nextWeekday(today, day), nil
+	}
+	if name, ok := strings.CutPrefix(expr, "next "); ok {
+		if day, ok := weekdays[name]; ok {
+			// The given day in the week after this one.
+			nextWeekStart := today.AddDate(0, 0, 7-mondayIndex(today.Weekday()))
+			return nextWeekStart.AddDate(0, 0, mondayIndex(day)), nil
+		}
+		switch name {
+		case "week":
+			return today.AddDate(0, 0, 7), nil
+		case "month":
+			return today.AddDate(0, 1, 0), nil
+		case "year":
+			return today.AddDate(1, 0, 0), nil
+		}
+	}
+
+	if t, ok := parseOffset(expr, now); ok {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognised date %q (try 2006-01-02, tomorrow, fri, next monday, in 3 days, eow)", expr)
+}
+
+// parseOffset handles "in N units" and the short form "Nu".
+func parseOffset(expr string, now time.Time) (time.Time, bool) {
+	var count int
+	var unit string
+	if rest, ok := strings.CutPrefix(expr, "in "); ok {
+		fields := strings.Fields(rest)
+		if len(fields) != 2 {
+			return time.Time{}, false
+		}
+		n, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return time.Time{}, false
+		}
+		count, unit = n, fields[1]
+	} else {
+		i := strings.IndexFunc(expr, func(r rune) bool { return r < '0' || r > '9' })
+		if i <= 0 {
+			return time.Time{}, false
+		}
+		n, err := strconv.Atoi(expr[:i])
+		if err != nil {
+			return time.Time{}, false
+		}
+		count, unit = n, expr[i:]
+	}
+
+	today := startOfDay(now)
+	switch unit {
+	case "d", "day", "days":
+		return today.AddDate(0, 0, count), true
+	case "w", "week", "weeks":
+		return today.AddDate(0, 0, 7*count), true
+	case "m", "month", "months":
+		return today.AddDate(0, count, 0), true
+	case "y", "year", "years":
+		return today.AddDate(count, 0, 0), true
+	case "h", "hour", "hours":
+		return now.Add(time.Duration(count) * time.Hour), true
+	case "min", "mins", "minute", "minutes":
+		return now.Add(time.Duration(count) * time.Minute), true
+	}
+	return time.Time{}, false
+}
+
+// parseClock parses a time of day such as "14:00", "9am" or "5:30pm".
+func parseClock(s string) (hour int, minute int, ok bool) {
+	suffix := ""
+	if strings.HasSuffix(s, "am") || strings.HasSuffix(s, "pm") {
+		suffix = s[len(s)-2:]
+		s = s[:len(s)-2]
+	}
+
+	hourPart, minutePart, hasMinutes := strings.Cut(s, ":")
+	if !hasMinutes && suffix == "" {
+		return 0, 0, false
+	}
+	h, err := strconv.Atoi(hourPart)
+	if err != nil {
+		return 0, 0, false
+	}
+	m := 0
+	if hasMinutes {
+		if m, err = strconv.Atoi(minutePart); err != nil || m < 0 || m > 59 {
+			return 0, 0, false
+		}
+	}
+
+	switch suffix {
+	case "am", "pm":
+		if h < 1 || h > 12 {
+			return 0, 0, false
+		}
+		h %= 12
+		if suffix == "pm" {
+			h += 12
+		}
+	default:
+		if h < 0 || h > 23 {
+			return 0, 0, false
+		}
+	}
+	return h, m, true
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// nextWeekday returns the first day after today that falls on day.
+func nextWeekday(today time.Time, day time.Weekday) time.Time {
+	diff := (int(day) - int(today.Weekday()) + 7) % 7
+	if diff == 0 {
+		diff = 7
+	}
+	return today.AddDate(0, 0, diff)
+}
+
+// mondayIndex numbers weekdays from Monday (0) to Sunday (6).
+func mondayIndex(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
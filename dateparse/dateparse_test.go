@@ -0,0 +1,73 @@
+package dateparse
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	// Wednesday afternoon.
+	now := time.Date(2025, 6, 4, 15, 30, 0, 0, time.UTC)
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2025, month, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"today", day(6, 4)},
+		{"Tomorrow", day(6, 5)},
+		{"yesterday", day(6, 3)},
+		{"fri", day(6, 6)},
+		{"wed", day(6, 11)},
+		{"monday", day(6, 9)},
+		{"next monday", day(6, 9)},
+		{"next fri", day(6, 13)},
+		{"in 3 days", day(6, 7)},
+		{"in 2 weeks", day(6, 18)},
+		{"2w", day(6, 18)},
+		{"1m", day(7, 4)},
+		{"eow", day(6, 8)},
+		{"eom", day(6, 30)},
+		{"2025-12-31", day(12, 31)},
+		{"2025-06-03T14:00", time.Date(2025, 6, 3, 14, 0, 0, 0, time.UTC)},
+		{"2025-06-03 09:15", time.Date(2025, 6, 3, 9, 15, 0, 0, time.UTC)},
+		{"tomorrow 14:00", time.Date(2025, 6, 5, 14, 0, 0, 0, time.UTC)},
+		{"fri 9am", time.Date(2025, 6, 6, 9, 0, 0, 0, time.UTC)},
+		{"next monday 5:30pm", time.Date(2025, 6, 9, 17, 30, 0, 0, time.UTC)},
+		{"in 4 hours", time.Date(2025, 6, 4, 19, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.expr, now)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("Parse(%q) = %s, want %s", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	now := time.Date(2025, 6, 4, 15, 30, 0, 0, time.UTC)
+	for _, expr := range []string{"", "someday", "in three days", "2025-13-01", "fri 25:00"} {
+		if _, err := Parse(expr, now); err == nil {
+			t.Errorf("Expected Parse(%q) to fail", expr)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	if got := Format(time.Time{}); got != "" {
+		t.Errorf("Expected empty string for zero time, got %q", got)
+	}
+	if got := Format(time.Date(2025, 6, 4, 0, 0, 0, 0, time.UTC)); got != "2025-06-04" {
+		t.Errorf("Expected date only, got %q", got)
+	}
+	if got := Format(time.Date(2025, 6, 4, 9, 5, 0, 0, time.UTC)); got != "2025-06-04 09:05" {
+		t.Errorf("Expected date and time, got %q", got)
+	}
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"github.com/kevin7254/task/cmd"
+	"github.com/kevin7254/task/config"
 	"github.com/kevin7254/task/store"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -14,14 +16,35 @@ func main() {
 		log.Fatalf("Error getting home directory: %v\n", osErr)
 	}
 
-	storageFile := filepath.Join(homeDir, ".task", "tasks.json")
-	jsonStore, storeErr := store.NewJsonStore(storageFile)
+	taskDir := filepath.Join(homeDir, ".task")
+	cfg, cfgErr := config.Load(taskDir)
+	if cfgErr != nil {
+		log.Fatalf("Error loading config: %v\n", cfgErr)
+	}
+
+	taskStore, storeErr := store.Open(cfg.Store.Backend, cfg.Store.Path, store.WithLockTimeout(cfg.Store.LockTimeout.Duration))
 	if storeErr != nil {
 		log.Fatalf("Error initializing storage: %v\n", storeErr)
 	}
 
-	rootCmdInstance := cmd.NewRootCmd(jsonStore)
-	if cobraErr := rootCmdInstance.Execute(); cobraErr != nil {
+	history := store.NewHistory(store.HistoryPath(cfg.Store.Path), store.WithLockTimeout(cfg.Store.LockTimeout.Duration))
+	var archive store.TaskRepository
+	openArchive := func() (store.TaskRepository, error) {
+		repo, err := store.Open(cfg.Store.Backend, cfg.Archive.Path, store.WithLockTimeout(cfg.Store.LockTimeout.Duration))
+		if err != nil {
+			return nil, err
+		}
+		archive = repo
+		return repo, nil
+	}
+	rootCmdInstance := cmd.NewRootCmd(store.NewRecorder(taskStore, history), cmd.WithConfig(cfg), cmd.WithArchive(openArchive))
+	cobraErr := rootCmdInstance.Execute()
+	for _, repo := range []store.TaskRepository{taskStore, archive} {
+		if closer, ok := repo.(io.Closer); ok {
+			_ = closer.Close()
+		}
+	}
+	if cobraErr != nil {
 		log.Fatalf("Error executing command: %v\n", cobraErr)
 	}
 }
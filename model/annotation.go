@@ -0,0 +1,87 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Annotation is a timestamped note added to a task.
+type Annotation struct {
+	Entry       time.Time `json:"entry"`
+	Description string    `json:"description"`
+}
+
+// ErrNoAnnotations is returned when removing an annotation from a task that
+// has none.
+var ErrNoAnnotations = errors.New("task has no annotations")
+
+// Annotate adds a note to the task at now, keeping the annotations in
+// chronological order.
+func (t *Task) Annotate(text string, now time.Time) (Annotation, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return Annotation{}, fmt.Errorf("annotation cannot be empty")
+	}
+	annotation := Annotation{Entry: now, Description: text}
+	i, _ := slices.BinarySearchFunc(t.Annotations, now, func(a Annotation, entry time.Time) int {
+		if a.Entry.After(entry) {
+			return 1
+		}
+		return -1
+	})
+	t.Annotations = slices.Insert(t.Annotations, i, annotation)
+	return annotation, nil
+}
+
+// FindAnnotation returns the index of the annotation whose text is match,
+// ignoring case, or else of the only one that contains match. An empty
+// match selects the latest annotation.
+func (t *Task) FindAnnotation(match string) (int, error) {
+	if len(t.Annotations) == 0 {
+		return 0, ErrNoAnnotations
+	}
+	match = strings.TrimSpace(match)
+	if match == "" {
+		return len(t.Annotations) - 1, nil
+	}
+	for i, annotation := range t.Annotations {
+		if strings.EqualFold(annotation.Description, match) {
+			return i, nil
+		}
+	}
+
+	found := -1
+	lower := strings.ToLower(match)
+	for i, annotation := range t.Annotations {
+		if !strings.Contains(strings.ToLower(annotation.Description), lower) {
+			continue
+		}
+		if found >= 0 {
+			return 0, fmt.Errorf("more than one annotation contains %q", match)
+		}
+		found = i
+	}
+	if found < 0 {
+		return 0, fmt.Errorf("no annotation matches %q", match)
+	}
+	return found, nil
+}
+
+// RemoveAnnotation removes and returns the annotation at index i.
+func (t *Task) RemoveAnnotation(i int) (Annotation, error) {
+	if len(t.Annotations) == 0 {
+		return Annotation{}, ErrNoAnnotations
+	}
+	if i < 0 || i >= len(t.Annotations) {
+		return Annotation{}, fmt.Errorf("annotation %d not found (the task has %d)", i+1, len(t.Annotations))
+	}
+	removed := t.Annotations[i]
+	t.Annotations = slices.Delete(t.Annotations, i, i+1)
+	if len(t.Annotations) == 0 {
+		t.Annotations = nil
+	}
+	return removed, nil
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTask_Annotate(t *testing.T) {
+	day := date(2025, 6, 2)
+	task := &Task{}
+	if _, err := task.Annotate("  ", day); err == nil {
+		t.Error("Expected an error for an empty annotation")
+	}
+	for _, a := range []Annotation{
+		{Entry: day.Add(2 * time.Hour), Description: "Second"},
+		{Entry: day, Description: "First"},
+		{Entry: day.Add(3 * time.Hour), Description: "Third"},
+	} {
+		if _, err := task.Annotate(a.Description, a.Entry); err != nil {
+			t.Fatalf("Annotate failed: %v", err)
+		}
+	}
+
+	for i, want := range []string{"First", "Second", "Third"} {
+		if got := task.Annotations[i].Description; got != want {
+			t.Errorf("annotation %d = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestTask_FindAndRemoveAnnotation(t *testing.T) {
+	task := &Task{}
+	if _, err := task.FindAnnotation(""); !errors.Is(err, ErrNoAnnotations) {
+		t.Errorf("Expected ErrNoAnnotations, got %v", err)
+	}
+	day := date(2025, 6, 2)
+	for i, text := range []string{"Called the supplier", "Supplier sent a quote", "Paid"} {
+		if _, err := task.Annotate(text, day.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatalf("Annotate failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		match   string
+		want    int
+		wantErr bool
+	}{
+		{"", 2, false},
+		{"paid", 2, false},
+		{"quote", 1, false},
+		{"supplier", 0, true},
+		{"refund", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := task.FindAnnotation(tt.match)
+		if (err != nil) != tt.wantErr || (!tt.wantErr && got != tt.want) {
+			t.Errorf("FindAnnotation(%q) = %d, %v; want %d (error %v)", tt.match, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	removed, err := task.RemoveAnnotation(1)
+	if err != nil || removed.Description != "Supplier sent a quote" || len(task.Annotations) != 2 {
+		t.Errorf("RemoveAnnotation(1) = %+v, %v; left %+v", removed, err, task.Annotations)
+	}
+	if _, err := task.RemoveAnnotation(5); err == nil {
+		t.Error("Expected an error for a missing annotation")
+	}
+}
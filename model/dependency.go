@@ -0,0 +1,99 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrDependencyCycle is returned when a dependency would make a task
+// (indirectly) depend on itself.
+var ErrDependencyCycle = errors.New("dependency cycle")
+
+// TaskLookup resolves a task ID to its task, returning nil for unknown IDs.
+type TaskLookup func(id int) *Task
+
+// IsBlocked reports whether any task this task depends on is still open.
+// Dependencies on tasks that were cancelled or no longer exist do not block.
+func (t *Task) IsBlocked(lookup TaskLookup) bool {
+	if lookup == nil {
+		return false
+	}
+	for _, id := range t.DependsOn {
+		if dep := lookup(id); dep != nil && !dep.IsClosed() {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveDependency drops id from the task's dependencies and reports whether
+// it was present.
+func (t *Task) RemoveDependency(id int) bool {
+	if !slices.Contains(t.DependsOn, id) {
+		return false
+	}
+	t.DependsOn = slices.DeleteFunc(t.DependsOn, func(dep int) bool { return dep == id })
+	if len(t.DependsOn) == 0 {
+		t.DependsOn = nil
+	}
+	return true
+}
+
+// ValidateDependencies checks that every dependency of t exists, is not t
+// itself and does not lead back to t through other tasks' dependencies.
+func ValidateDependencies(t *Task, lookup TaskLookup) error {
+	for _, id := range t.DependsOn {
+		if id == t.ID && t.ID != 0 {
+			return fmt.Errorf("%w: task %d cannot depend on itself", ErrDependencyCycle, id)
+		}
+		if lookup(id) == nil {
+			return fmt.Errorf("dependency task with ID %d not found", id)
+		}
+	}
+	if t.ID == 0 {
+		// A task that isn't stored yet cannot be depended on.
+		return nil
+	}
+
+	for _, id := range t.DependsOn {
+		if path := dependencyPath(id, t.ID, lookup, map[int]bool{}); path != nil {
+			return fmt.Errorf("%w: %s", ErrDependencyCycle, formatPath(append([]int{t.ID}, path...)))
+		}
+	}
+	return nil
+}
+
+// dependencyPath returns the chain of dependencies leading from id to target,
+// or nil if target is not reachable.
+func dependencyPath(id int, target int, lookup TaskLookup, visited map[int]bool) []int {
+	if id == target {
+		return []int{id}
+	}
+	if visited[id] {
+		return nil
+	}
+	visited[id] = true
+
+	task := lookup(id)
+	if task == nil {
+		return nil
+	}
+	for _, dep := range task.DependsOn {
+		if path := dependencyPath(dep, target, lookup, visited); path != nil {
+			return append([]int{id}, path...)
+		}
+	}
+	return nil
+}
+
+// formatPath renders a dependency chain such as "7 -> 4 -> 7".
+func formatPath(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, " -> ")
+}
@@ -0,0 +1,356 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the base unit a recurring task repeats in.
+type Frequency string
+
+const (
+	Daily   Frequency = "daily"
+	Weekly  Frequency = "weekly"
+	Monthly Frequency = "monthly"
+)
+
+// Recurrence describes when the next instance of a recurring task is due.
+type Recurrence struct {
+	Frequency Frequency `json:"frequency"`
+	// Interval repeats every N days, weeks or months. Zero means 1.
+	Interval int `json:"interval,omitempty"`
+	// Weekdays restricts weekly recurrences to the given days.
+	Weekdays []time.Weekday `json:"weekdays,omitempty"`
+	// MonthDay is the day of the month for monthly recurrences. Days past the
+	// end of a short month fall on its last day.
+	MonthDay int `json:"month_day,omitempty"`
+	// Until is the last day an instance may be due on. Zero means forever.
+	Until time.Time `json:"until,omitzero"`
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"su": time.Sunday, "sun": time.Sunday, "sunday": time.Sunday,
+	"mo": time.Monday, "mon": time.Monday, "monday": time.Monday,
+	"tu": time.Tuesday, "tue": time.Tuesday, "tuesday": time.Tuesday,
+	"we": time.Wednesday, "wed": time.Wednesday, "wednesday": time.Wednesday,
+	"th": time.Thursday, "thu": time.Thursday, "thursday": time.Thursday,
+	"fr": time.Friday, "fri": time.Friday, "friday": time.Friday,
+	"sa": time.Saturday, "sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// ParseRecurrence parses a recurrence rule. Supported forms are:
+//
+//	daily, weekly, monthly
+//	weekly:mon,thu       every week on the given weekdays
+//	monthly:15           every month on the 15th
+//	every 3 days, 3d     every N days (also weeks/w and months/m)
+//	RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;UNTIL=20251231
+//
+// Any short form may end in "until 2006-01-02", as produced by String.
+func ParseRecurrence(spec string) (*Recurrence, error) {
+	spec = strings.TrimSpace(spec)
+	lower := strings.ToLower(spec)
+
+	if rule, untilSpec, ok := strings.Cut(lower, " until "); ok && !strings.Contains(rule, "=") {
+		until, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(untilSpec), time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid until date %q", untilSpec)
+		}
+		r, err := ParseRecurrence(rule)
+		if err != nil {
+			return nil, err
+		}
+		r.Until = until
+		return r, nil
+	}
+
+	switch {
+	case strings.HasPrefix(lower, "rrule:") || strings.HasPrefix(lower, "freq="):
+		return parseRRule(strings.TrimPrefix(lower, "rrule:"))
+	case lower == "daily" || lower == "weekly" || lower == "monthly":
+		return &Recurrence{Frequency: Frequency(lower)}, nil
+	case strings.HasPrefix(lower, "weekly:"):
+		days, err := parseWeekdays(strings.TrimPrefix(lower, "weekly:"))
+		if err != nil {
+			return nil, err
+		}
+		return &Recurrence{Frequency: Weekly, Weekdays: days}, nil
+	case strings.HasPrefix(lower, "monthly:"):
+		day, err := parseMonthDay(strings.TrimPrefix(lower, "monthly:"))
+		if err != nil {
+			return nil, err
+		}
+		return &Recurrence{Frequency: Monthly, MonthDay: day}, nil
+	}
+
+	if r, ok := parseEvery(lower); ok {
+		return r, nil
+	}
+	return nil, fmt.Errorf("unrecognised recurrence %q (try daily, weekly:mon,fri, monthly:15, every 3 days or an RRULE)", spec)
+}
+
+// parseEvery parses "every N units" and the short form "Nu".
+func parseEvery(spec string) (*Recurrence, bool) {
+	var count int
+	var unit string
+	if rest, ok := strings.CutPrefix(spec, "every "); ok {
+		fields := strings.Fields(rest)
+		switch len(fields) {
+		case 1:
+			count, unit = 1, fields[0]
+		case 2:
+			n, err := strconv.Atoi(fields[0])
+			if err != nil {
+				return nil, false
+			}
+			count, unit = n, fields[1]
+		default:
+			return nil, false
+		}
+	} else {
+		i := strings.IndexFunc(spec, func(r rune) bool { return r < '0' || r > '9' })
+		if i <= 0 {
+			return nil, false
+		}
+		n, err := strconv.Atoi(spec[:i])
+		if err != nil {
+			return nil, false
+		}
+		count, unit = n, spec[i:]
+	}
+	if count < 1 {
+		return nil, false
+	}
+
+	switch unit {
+	case "d", "day", "days":
+		return &Recurrence{Frequency: Daily, Interval: count}, true
+	case "w", "week", "weeks":
+		return &Recurrence{Frequency: Weekly, Interval: count}, true
+	case "m", "month", "months":
+		return &Recurrence{Frequency: Monthly, Interval: count}, true
+	}
+	return nil, false
+}
+
+// parseRRule parses the FREQ, INTERVAL, BYDAY, BYMONTHDAY and UNTIL parts of
+// an iCalendar RRULE.
+func parseRRule(rule string) (*Recurrence, error) {
+	r := &Recurrence{}
+	for _, part := range strings.Split(rule, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid RRULE part %q", part)
+		}
+		switch key {
+		case "freq":
+			switch value {
+			case "daily", "weekly", "monthly":
+				r.Frequency = Frequency(value)
+			default:
+				return nil, fmt.Errorf("unsupported RRULE frequency %q", value)
+			}
+		case "interval":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid RRULE interval %q", value)
+			}
+			r.Interval = n
+		case "byday":
+			days, err := parseWeekdays(value)
+			if err != nil {
+				return nil, err
+			}
+			r.Weekdays = days
+		case "bymonthday":
+			day, err := parseMonthDay(value)
+			if err != nil {
+				return nil, err
+			}
+			r.MonthDay = day
+		case "until":
+			until, err := time.ParseInLocation("20060102", value[:min(len(value), 8)], time.Local)
+			if err != nil {
+				return nil, fmt.Errorf("invalid RRULE until %q", value)
+			}
+			r.Until = until
+		default:
+			return nil, fmt.Errorf("unsupported RRULE part %q", strings.ToUpper(key))
+		}
+	}
+	if r.Frequency == "" {
+		return nil, fmt.Errorf("RRULE is missing FREQ")
+	}
+	return r, nil
+}
+
+func parseWeekdays(list string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, name := range strings.Split(list, ",") {
+		day, ok := weekdayNames[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown weekday %q", name)
+		}
+		if !slices.Contains(days, day) {
+			days = append(days, day)
+		}
+	}
+	slices.SortFunc(days, func(a, b time.Weekday) int { return mondayIndex(a) - mondayIndex(b) })
+	return days, nil
+}
+
+func parseMonthDay(value string) (int, error) {
+	day, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || day < 1 || day > 31 {
+		return 0, fmt.Errorf("invalid day of month %q", value)
+	}
+	return day, nil
+}
+
+// mondayIndex numbers weekdays from Monday (0) to Sunday (6).
+func mondayIndex(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
+
+func (r *Recurrence) interval() int {
+	if r.Interval < 1 {
+		return 1
+	}
+	return r.Interval
+}
+
+// Next returns the first due date after from. It returns false when that
+// date falls after Until.
+func (r *Recurrence) Next(from time.Time) (time.Time, bool) {
+	var next time.Time
+	switch r.Frequency {
+	case Weekly:
+		next = r.nextWeekly(from)
+	case Monthly:
+		next = r.nextMonthly(from)
+	default:
+		next = from.AddDate(0, 0, r.interval())
+	}
+
+	if !r.Until.IsZero() {
+		endOfUntil := time.Date(r.Until.Year(), r.Until.Month(), r.Until.Day(), 23, 59, 59, 0, r.Until.Location())
+		if next.After(endOfUntil) {
+			return time.Time{}, false
+		}
+	}
+	return next, true
+}
+
+func (r *Recurrence) nextWeekly(from time.Time) time.Time {
+	if len(r.Weekdays) == 0 {
+		return from.AddDate(0, 0, 7*r.interval())
+	}
+
+	// A later weekday in the same week comes first.
+	current := mondayIndex(from.Weekday())
+	for _, day := range r.Weekdays {
+		if idx := mondayIndex(day); idx > current {
+			return from.AddDate(0, 0, idx-current)
+		}
+	}
+	// Otherwise the first listed weekday, interval weeks later.
+	weekStart := from.AddDate(0, 0, -current)
+	return weekStart.AddDate(0, 0, 7*r.interval()+mondayIndex(r.Weekdays[0]))
+}
+
+func (r *Recurrence) nextMonthly(from time.Time) time.Time {
+	day := r.MonthDay
+	if day == 0 {
+		day = from.Day()
+	}
+
+	if candidate := onMonthDay(from, 0, day); candidate.After(from) && r.MonthDay != 0 {
+		return candidate
+	}
+	return onMonthDay(from, r.interval(), day)
+}
+
+// onMonthDay returns from moved forward by months, on day (clamped to the
+// length of that month), keeping the time of day.
+func onMonthDay(from time.Time, months int, day int) time.Time {
+	first := time.Date(from.Year(), from.Month()+time.Month(months), 1, from.Hour(), from.Minute(), from.Second(), from.Nanosecond(), from.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(day, lastDay)-1)
+}
+
+// String renders the rule in the short form accepted by ParseRecurrence.
+func (r *Recurrence) String() string {
+	var s string
+	switch {
+	case r.Frequency == Weekly && len(r.Weekdays) > 0 && r.interval() == 1:
+		names := make([]string, len(r.Weekdays))
+		for i, day := range r.Weekdays {
+			names[i] = strings.ToLower(day.String()[:3])
+		}
+		s = "weekly:" + strings.Join(names, ",")
+	case r.Frequency == Monthly && r.MonthDay != 0 && r.interval() == 1:
+		s = fmt.Sprintf("monthly:%d", r.MonthDay)
+	case r.interval() == 1 && len(r.Weekdays) == 0 && r.MonthDay == 0:
+		s = string(r.Frequency)
+	default:
+		s = r.rrule()
+	}
+	if !r.Until.IsZero() && !strings.HasPrefix(s, "RRULE:") {
+		s += " until " + r.Until.Format("2006-01-02")
+	}
+	return s
+}
+
+// rrule renders the rule as an iCalendar RRULE.
+func (r *Recurrence) rrule() string {
+	parts := []string{"FREQ=" + strings.ToUpper(string(r.Frequency))}
+	if r.interval() > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.interval()))
+	}
+	if len(r.Weekdays) > 0 {
+		days := make([]string, len(r.Weekdays))
+		for i, day := range r.Weekdays {
+			days[i] = strings.ToUpper(day.String()[:2])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.MonthDay != 0 {
+		parts = append(parts, fmt.Sprintf("BYMONTHDAY=%d", r.MonthDay))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+	}
+	return "RRULE:" + strings.Join(parts, ";")
+}
+
+// NextOccurrence returns a new, pending copy of a recurring task that is due
+// at the next date of its recurrence. It returns nil if the task does not
+// recur or the recurrence has ended.
+func (t *Task) NextOccurrence() *Task {
+	if t.Recur == nil {
+		return nil
+	}
+
+	from := t.DueDate
+	if from.IsZero() {
+		from = t.CompletedAt
+	}
+	if from.IsZero() {
+		from = time.Now()
+	}
+	due, ok := t.Recur.Next(from)
+	if !ok {
+		return nil
+	}
+
+	recur := *t.Recur
+	recur.Weekdays = slices.Clone(t.Recur.Weekdays)
+	next := NewTask(t.Title, t.Description, t.Project, t.Priority, due)
+	next.Tags = slices.Clone(t.Tags)
+	next.ParentID = t.ParentID
+	next.Recur = &recur
+	return next
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 9, 0, 0, 0, time.UTC)
+}
+
+func TestRecurrence_Next(t *testing.T) {
+	tests := []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"daily", date(2025, 6, 2), date(2025, 6, 3)},
+		{"every 3 days", date(2025, 6, 2), date(2025, 6, 5)},
+		{"2w", date(2025, 6, 2), date(2025, 6, 16)},
+		{"weekly", date(2025, 6, 6), date(2025, 6, 13)},
+		// Monday -> Friday of the same week, Friday -> Monday of the next.
+		{"weekly:mon,fri", date(2025, 6, 2), date(2025, 6, 6)},
+		{"weekly:mon,fri", date(2025, 6, 6), date(2025, 6, 9)},
+		{"monthly", date(2025, 1, 31), date(2025, 2, 28)},
+		{"monthly:15", date(2025, 6, 2), date(2025, 6, 15)},
+		{"monthly:15", date(2025, 6, 15), date(2025, 7, 15)},
+		{"monthly:31", date(2025, 4, 30), date(2025, 5, 31)},
+		{"RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE", date(2025, 6, 4), date(2025, 6, 16)},
+		{"FREQ=MONTHLY;BYMONTHDAY=1", date(2025, 12, 1), date(2026, 1, 1)},
+	}
+
+	for _, tt := range tests {
+		rule, err := ParseRecurrence(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseRecurrence(%q) failed: %v", tt.spec, err)
+		}
+		got, ok := rule.Next(tt.from)
+		if !ok || !got.Equal(tt.want) {
+			t.Errorf("%q from %s: want %s, got %s (ok=%v)", tt.spec, tt.from.Format("Mon 2006-01-02"), tt.want.Format("Mon 2006-01-02"), got.Format("Mon 2006-01-02"), ok)
+		}
+	}
+}
+
+func TestRecurrence_Until(t *testing.T) {
+	rule, err := ParseRecurrence("RRULE:FREQ=DAILY;UNTIL=20250603")
+	if err != nil {
+		t.Fatalf("ParseRecurrence failed: %v", err)
+	}
+	if _, ok := rule.Next(date(2025, 6, 2)); !ok {
+		t.Errorf("Expected an occurrence on the until date")
+	}
+	if _, ok := rule.Next(date(2025, 6, 3)); ok {
+		t.Errorf("Expected no occurrence after the until date")
+	}
+}
+
+func TestParseRecurrence_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "hourly", "weekly:funday", "monthly:32", "every 0 days", "FREQ=YEARLY"} {
+		if _, err := ParseRecurrence(spec); err == nil {
+			t.Errorf("Expected ParseRecurrence(%q) to fail", spec)
+		}
+	}
+}
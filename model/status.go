@@ -0,0 +1,106 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Status is the stage of a task's workflow.
+type Status string
+
+const (
+	StatusPending    Status = "pending"
+	StatusInProgress Status = "in-progress"
+	// StatusWaiting marks a task that waits on something outside the task
+	// list, such as a reply.
+	StatusWaiting Status = "waiting"
+	// StatusBlocked marks a task that cannot go on for a reason not recorded
+	// as a dependency.
+	StatusBlocked   Status = "blocked"
+	StatusDone      Status = "done"
+	StatusCancelled Status = "cancelled"
+)
+
+// Statuses lists every status in workflow order.
+var Statuses = []Status{StatusPending, StatusInProgress, StatusWaiting, StatusBlocked, StatusDone, StatusCancelled}
+
+// ParseStatus parses a status name. "completed" is accepted for done and
+// "canceled" for cancelled.
+func ParseStatus(s string) (Status, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	switch name {
+	case "completed":
+		return StatusDone, nil
+	case "canceled":
+		return StatusCancelled, nil
+	case "in_progress", "inprogress", "started":
+		return StatusInProgress, nil
+	}
+	for _, status := range Statuses {
+		if name == string(status) {
+			return status, nil
+		}
+	}
+	names := make([]string, len(Statuses))
+	for i, status := range Statuses {
+		names[i] = string(status)
+	}
+	return "", fmt.Errorf("unknown status %q (expected one of %s)", s, strings.Join(names, ", "))
+}
+
+// IsClosed reports whether the status ends the workflow.
+func (s Status) IsClosed() bool {
+	return s == StatusDone || s == StatusCancelled
+}
+
+// CanTransition reports whether a task may move from status s to next. Open
+// tasks may move to any other status; done and cancelled tasks can only be
+// reopened as pending.
+func (s Status) CanTransition(next Status) bool {
+	if s == next {
+		return false
+	}
+	if s.IsClosed() {
+		return next == StatusPending
+	}
+	return true
+}
+
+// State returns the task's status, treating an unset status as pending.
+func (t *Task) State() Status {
+	if t.Status == "" {
+		return StatusPending
+	}
+	return t.Status
+}
+
+// IsClosed reports whether the task is done or cancelled.
+func (t *Task) IsClosed() bool {
+	return t.State().IsClosed()
+}
+
+// SetStatus moves the task to status at now, refusing transitions the
+// workflow does not allow. Closing a task records when it was closed in
+// CompletedAt and stops its time tracking; reopening it clears CompletedAt.
+func (t *Task) SetStatus(status Status, now time.Time) error {
+	current := t.State()
+	if current == status {
+		return fmt.Errorf("task is already %s", status)
+	}
+	if !current.CanTransition(status) {
+		return fmt.Errorf("cannot change a %s task to %s; reopen it first", current, status)
+	}
+
+	switch {
+	case status.IsClosed():
+		if t.IsActive() {
+			_, _ = t.Stop(now)
+		}
+		t.CompletedAt = now
+	case current.IsClosed():
+		t.CompletedAt = time.Time{}
+	}
+	t.Status = status
+	return nil
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseStatus(t *testing.T) {
+	tests := map[string]Status{
+		"pending":     StatusPending,
+		"In-Progress": StatusInProgress,
+		"started":     StatusInProgress,
+		"completed":   StatusDone,
+		"canceled":    StatusCancelled,
+	}
+	for input, want := range tests {
+		if got, err := ParseStatus(input); err != nil || got != want {
+			t.Errorf("ParseStatus(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseStatus("finished"); err == nil {
+		t.Error("Expected an error for an unknown status")
+	}
+}
+
+func TestTask_SetStatus(t *testing.T) {
+	now := date(2025, 6, 2)
+	task := &Task{}
+	if task.State() != StatusPending {
+		t.Fatalf("Expected a task without status to be pending, got %q", task.State())
+	}
+	if err := task.Start(now); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if err := task.SetStatus(StatusWaiting, now); err != nil {
+		t.Fatalf("SetStatus(waiting) failed: %v", err)
+	}
+	if err := task.SetStatus(StatusWaiting, now); err == nil {
+		t.Error("Expected an error when the status does not change")
+	}
+
+	later := now.Add(time.Hour)
+	if err := task.SetStatus(StatusCancelled, later); err != nil {
+		t.Fatalf("SetStatus(cancelled) failed: %v", err)
+	}
+	if !task.IsClosed() || !task.CompletedAt.Equal(later) || task.IsActive() {
+		t.Errorf("Expected cancelling to close the task and stop its clock, got %+v", task)
+	}
+	if err := task.SetStatus(StatusDone, later); err == nil {
+		t.Error("Expected a cancelled task to only be reopened")
+	}
+
+	if err := task.SetStatus(StatusPending, later); err != nil {
+		t.Fatalf("Reopening failed: %v", err)
+	}
+	if task.IsClosed() || !task.CompletedAt.IsZero() {
+		t.Errorf("Expected reopening to clear the completion time, got %+v", task)
+	}
+}
@@ -2,6 +2,9 @@ package model
 
 import (
 	"fmt"
+	"github.com/kevin7254/task/dateparse"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -14,55 +17,147 @@ const (
 )
 
 type Task struct {
-	ID          int       `json:"id"`
+	ID int `json:"id"`
+	// UUID identifies the task permanently; it is assigned when the task is
+	// first stored and never changes.
+	UUID        string    `json:"uuid"`
 	Title       string    `json:"title"`
 	Description string    `json:"description"`
 	Project     string    `json:"project"`
 	Priority    Priority  `json:"priority"`
+	Status      Status    `json:"status"`
 	DueDate     time.Time `json:"due_date"`
 	CreatedAt   time.Time `json:"created_at"`
+	// CompletedAt is when the task was done or cancelled.
 	CompletedAt time.Time `json:"completed_at"`
-	TimeSpent   int64     `json:"time_spent"`
+	// ArchivedAt is when the task was last moved to the archive. It is kept
+	// when the task is brought back, so that it is not archived again
+	// automatically.
+	ArchivedAt  time.Time    `json:"archived_at"`
+	TimeEntries []TimeEntry  `json:"time_entries,omitempty"`
+	Tags        []string     `json:"tags,omitempty"`
+	ParentID    int          `json:"parent_id,omitempty"`
+	DependsOn   []int        `json:"depends_on,omitempty"`
+	Recur       *Recurrence  `json:"recur,omitempty"`
+	Annotations []Annotation `json:"annotations,omitempty"`
 }
 
 func NewTask(title string, description string, project string, priority Priority, dueDate time.Time) *Task {
 	return &Task{
 		ID:          0,
+		UUID:        NewUUID(),
 		Title:       title,
 		Description: description,
 		Project:     project,
 		Priority:    priority,
+		Status:      StatusPending,
 		DueDate:     dueDate,
 		CreatedAt:   time.Now(),
 	}
 }
 
+// Clone returns a deep copy of the task, so changes to the copy never affect
+// the original.
+func (t *Task) Clone() *Task {
+	clone := *t
+	clone.Tags = slices.Clone(t.Tags)
+	clone.DependsOn = slices.Clone(t.DependsOn)
+	clone.TimeEntries = slices.Clone(t.TimeEntries)
+	clone.Annotations = slices.Clone(t.Annotations)
+	if t.Recur != nil {
+		recur := *t.Recur
+		recur.Weekdays = slices.Clone(t.Recur.Weekdays)
+		clone.Recur = &recur
+	}
+	return &clone
+}
+
 func (t *Task) String() string {
 	status := "⏳"
-	if !t.CompletedAt.IsZero() {
+	switch {
+	case t.State() == StatusDone:
 		status = "✅"
-	} else if t.IsOverdue() {
+	case t.State() == StatusCancelled:
+		status = "✖️"
+	case t.IsOverdue():
 		status = "⚠️"
 	}
 
 	return fmt.Sprintf(
-		"\nStatus: %s\nTitle: %s\nProject: %s\nDue Date: %s\nTime Spent: %d min\n",
+		"\nStatus: %s\nTitle: %s\nProject: %s\nTags: %s\nDue Date: %s\nTime Spent: %d min\n",
 		status,
 		t.Title,
 		t.Project,
-		t.DueDate.Format("2006-01-02"),
-		t.TimeSpent,
+		strings.Join(t.Tags, ", "),
+		dateparse.Format(t.DueDate),
+		t.TimeSpent(),
 	)
 }
 
+// IsOverdue reports whether the due date has passed. Tasks without a due date
+// are never overdue, and a date without a time of day lasts the whole day.
 func (t *Task) IsOverdue() bool {
-	return t.DueDate.Before(time.Now())
+	return t.isOverdueAt(time.Now())
+}
+
+func (t *Task) isOverdueAt(now time.Time) bool {
+	if t.DueDate.IsZero() {
+		return false
+	}
+	return !now.Before(t.deadline())
 }
 
+// deadline is the moment the task becomes overdue: the due time, or the end
+// of the due day for a date without a time of day.
+func (t *Task) deadline() time.Time {
+	if dateparse.IsDateOnly(t.DueDate) {
+		return t.DueDate.AddDate(0, 0, 1)
+	}
+	return t.DueDate
+}
+
+// Complete marks the task as done now, stopping its time tracking. Unlike
+// SetStatus, it also completes a task that is already closed.
 func (t *Task) Complete() {
-	t.CompletedAt = time.Now()
+	now := time.Now()
+	if t.IsActive() {
+		_, _ = t.Stop(now)
+	}
+	t.Status = StatusDone
+	t.CompletedAt = now
 }
 
-func (t *Task) AddTimeSpent(minutes int64) {
-	t.TimeSpent += minutes
+// NormalizeTag returns the canonical form of a tag: lower case, without the
+// leading "+" used on the command line.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "+"))
+}
+
+// HasTag reports whether the task carries the given tag.
+func (t *Task) HasTag(tag string) bool {
+	return slices.Contains(t.Tags, NormalizeTag(tag))
+}
+
+// AddTags adds the given tags, ignoring empty tags and ones already present.
+func (t *Task) AddTags(tags ...string) {
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag != "" && !slices.Contains(t.Tags, tag) {
+			t.Tags = append(t.Tags, tag)
+		}
+	}
+	slices.Sort(t.Tags)
+}
+
+// RemoveTags removes the given tags if present.
+func (t *Task) RemoveTags(tags ...string) {
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		t.Tags = slices.DeleteFunc(t.Tags, func(existing string) bool {
+			return existing == tag
+		})
+	}
+	if len(t.Tags) == 0 {
+		t.Tags = nil
+	}
 }
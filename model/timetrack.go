@@ -0,0 +1,175 @@
+package model
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+var (
+	// ErrAlreadyStarted is returned when starting a task whose time is
+	// already being tracked.
+	ErrAlreadyStarted = errors.New("task is already started")
+	// ErrNotStarted is returned when stopping a task that is not started.
+	ErrNotStarted = errors.New("task is not started")
+)
+
+// TimeEntry is an interval of work on a task.
+type TimeEntry struct {
+	Start time.Time `json:"start"`
+	// End is zero while the task is being worked on.
+	End time.Time `json:"end"`
+	// Legacy marks the entry holding time recorded as a plain number of
+	// minutes before time tracking; its interval is only approximate.
+	Legacy bool `json:"legacy,omitempty"`
+}
+
+// Duration returns the length of the entry, counting a running entry up to now.
+func (e TimeEntry) Duration(now time.Time) time.Duration {
+	end := e.End
+	if end.IsZero() {
+		end = now
+	}
+	return max(end.Sub(e.Start), 0)
+}
+
+// IsActive reports whether time is currently being tracked on the task.
+func (t *Task) IsActive() bool {
+	n := len(t.TimeEntries)
+	return n > 0 && t.TimeEntries[n-1].End.IsZero()
+}
+
+// ActiveSince returns when the running time entry started, or the zero time
+// if the task is not active.
+func (t *Task) ActiveSince() time.Time {
+	if !t.IsActive() {
+		return time.Time{}
+	}
+	return t.TimeEntries[len(t.TimeEntries)-1].Start
+}
+
+// Start begins a time entry at now.
+func (t *Task) Start(now time.Time) error {
+	if t.IsActive() {
+		return ErrAlreadyStarted
+	}
+	t.TimeEntries = append(t.TimeEntries, TimeEntry{Start: now})
+	return nil
+}
+
+// Stop ends the running time entry at now and returns its length.
+func (t *Task) Stop(now time.Time) (time.Duration, error) {
+	if !t.IsActive() {
+		return 0, ErrNotStarted
+	}
+	entry := &t.TimeEntries[len(t.TimeEntries)-1]
+	entry.End = now
+	// A clock that went backwards must not produce a negative interval.
+	if entry.End.Before(entry.Start) {
+		entry.End = entry.Start
+	}
+	return entry.Duration(now), nil
+}
+
+// Track records d of work starting at start. Entries are kept in order of
+// their start time, with a running entry always last.
+func (t *Task) Track(start time.Time, d time.Duration) {
+	entry := TimeEntry{Start: start, End: start.Add(d)}
+	closed := len(t.TimeEntries)
+	if t.IsActive() {
+		closed--
+	}
+	i := closed
+	for i > 0 && t.TimeEntries[i-1].Start.After(start) {
+		i--
+	}
+	t.TimeEntries = slices.Insert(t.TimeEntries, i, entry)
+}
+
+// Tracked returns the total time tracked on the task, counting a running
+// entry up to now.
+func (t *Task) Tracked(now time.Time) time.Duration {
+	var total time.Duration
+	for _, entry := range t.TimeEntries {
+		total += entry.Duration(now)
+	}
+	return total
+}
+
+// TimeSpent returns the total time tracked on the task in whole minutes.
+func (t *Task) TimeSpent() int64 {
+	return int64(t.Tracked(time.Now()) / time.Minute)
+}
+
+// SetTimeSpent adjusts the tracked time to the given number of minutes at
+// now: more time is added as an entry ending now, and less is taken from the
+// most recent closed entries first. A running entry keeps running; if time
+// has to come off it too, it starts later instead.
+func (t *Task) SetTimeSpent(minutes int64, now time.Time) {
+	want := time.Duration(minutes) * time.Minute
+	have := t.Tracked(now)
+	entries := slices.Clone(t.TimeEntries)
+	switch {
+	case want > have:
+		t.TimeEntries = entries
+		t.Track(now.Add(have-want), want-have)
+		return
+	case want < have:
+		excess := have - want
+		closed := len(entries)
+		if t.IsActive() {
+			closed--
+		}
+		for i := closed - 1; i >= 0 && excess > 0; i-- {
+			length := entries[i].Duration(now)
+			if length <= excess {
+				excess -= length
+				entries = slices.Delete(entries, i, i+1)
+				continue
+			}
+			entries[i].End = entries[i].Start.Add(length - excess)
+			excess = 0
+		}
+		if excess > 0 && t.IsActive() {
+			running := &entries[len(entries)-1]
+			running.Start = running.Start.Add(excess)
+			if running.Start.After(now) {
+				running.Start = now
+			}
+		}
+	}
+	if len(entries) == 0 {
+		entries = nil
+	}
+	t.TimeEntries = entries
+}
+
+// TimeSegments returns the time tracked on the task between from and to,
+// split at midnight in now's location so that every segment lies within a
+// single day. A running entry counts up to now, and a zero from or to leaves
+// that end of the range open.
+func (t *Task) TimeSegments(from, to, now time.Time) []TimeEntry {
+	var segments []TimeEntry
+	for _, entry := range t.TimeEntries {
+		start, end := entry.Start.In(now.Location()), entry.End.In(now.Location())
+		if entry.End.IsZero() {
+			end = now
+		}
+		if !from.IsZero() && start.Before(from) {
+			start = from
+		}
+		if !to.IsZero() && end.After(to) {
+			end = to
+		}
+		for start.Before(end) {
+			midnight := time.Date(start.Year(), start.Month(), start.Day()+1, 0, 0, 0, 0, start.Location())
+			segmentEnd := end
+			if midnight.Before(end) {
+				segmentEnd = midnight
+			}
+			segments = append(segments, TimeEntry{Start: start, End: segmentEnd, Legacy: entry.Legacy})
+			start = segmentEnd
+		}
+	}
+	return segments
+}
@@ -0,0 +1,133 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTask_StartStop(t *testing.T) {
+	start := date(2025, 6, 2)
+	task := &Task{}
+
+	if err := task.Start(start); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if !task.IsActive() || !task.ActiveSince().Equal(start) {
+		t.Fatalf("Expected task to be active since %v, got %+v", start, task.TimeEntries)
+	}
+	if err := task.Start(start); !errors.Is(err, ErrAlreadyStarted) {
+		t.Errorf("Expected ErrAlreadyStarted, got %v", err)
+	}
+	if got := task.Tracked(start.Add(10 * time.Minute)); got != 10*time.Minute {
+		t.Errorf("Expected a running entry to count up to now, got %v", got)
+	}
+
+	spent, err := task.Stop(start.Add(25 * time.Minute))
+	if err != nil || spent != 25*time.Minute {
+		t.Fatalf("Stop() = %v, %v; want 25m", spent, err)
+	}
+	if task.IsActive() {
+		t.Error("Expected task to be inactive after Stop")
+	}
+	if _, err := task.Stop(start); !errors.Is(err, ErrNotStarted) {
+		t.Errorf("Expected ErrNotStarted, got %v", err)
+	}
+}
+
+func TestTask_Track(t *testing.T) {
+	day := date(2025, 6, 2)
+	task := &Task{}
+	if err := task.Start(day.Add(5 * time.Hour)); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	task.Track(day.Add(2*time.Hour), 30*time.Minute)
+	task.Track(day, time.Hour)
+
+	if len(task.TimeEntries) != 3 || !task.TimeEntries[0].Start.Equal(day) || !task.IsActive() {
+		t.Fatalf("Expected entries in start order with the running one last, got %+v", task.TimeEntries)
+	}
+	if got := task.Tracked(day.Add(6 * time.Hour)); got != 150*time.Minute {
+		t.Errorf("Expected 150m tracked, got %v", got)
+	}
+}
+
+func TestTask_SetTimeSpent(t *testing.T) {
+	day := date(2025, 6, 2)
+	now := day.Add(8 * time.Hour)
+	task := &Task{}
+	task.Track(day, time.Hour)
+	task.Track(day.Add(2*time.Hour), time.Hour)
+
+	task.SetTimeSpent(90, now)
+	if got := task.Tracked(now); got != 90*time.Minute || len(task.TimeEntries) != 2 {
+		t.Errorf("Expected the latest entry to be shortened to 90m in total, got %v in %+v", got, task.TimeEntries)
+	}
+
+	task.SetTimeSpent(120, now)
+	last := task.TimeEntries[len(task.TimeEntries)-1]
+	if got := task.Tracked(now); got != 2*time.Hour || !last.End.Equal(now) {
+		t.Errorf("Expected 30m added as an entry ending now, got %v in %+v", got, task.TimeEntries)
+	}
+
+	task.SetTimeSpent(0, now)
+	if task.TimeEntries != nil {
+		t.Errorf("Expected all entries removed, got %+v", task.TimeEntries)
+	}
+}
+
+func TestTask_SetTimeSpent_KeepsTimerRunning(t *testing.T) {
+	day := date(2025, 6, 2)
+	now := day.Add(8 * time.Hour)
+	task := &Task{}
+	task.Track(day, time.Hour)
+	if err := task.Start(now.Add(-30 * time.Minute)); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	task.SetTimeSpent(60, now)
+	if got := task.Tracked(now); got != time.Hour || !task.IsActive() {
+		t.Errorf("Expected 60m with the timer still running, got %v in %+v", got, task.TimeEntries)
+	}
+	if got := task.ActiveSince(); !got.Equal(now.Add(-30 * time.Minute)) {
+		t.Errorf("Expected the closed entry to be shortened first, got the timer started at %v", got)
+	}
+
+	task.SetTimeSpent(10, now)
+	if got := task.Tracked(now); got != 10*time.Minute || !task.IsActive() || len(task.TimeEntries) != 1 {
+		t.Errorf("Expected the running entry to start later, got %v in %+v", got, task.TimeEntries)
+	}
+	if got := task.Tracked(now.Add(5 * time.Minute)); got != 15*time.Minute {
+		t.Errorf("Expected the timer to keep counting, got %v", got)
+	}
+}
+
+func TestTask_TimeSegments(t *testing.T) {
+	at := func(day, hour int) time.Time { return time.Date(2025, 6, day, hour, 0, 0, 0, time.UTC) }
+	now := at(12, 12)
+	task := &Task{TimeEntries: []TimeEntry{
+		{Start: at(8, 22), End: at(10, 2)},
+		{Start: at(12, 10)},
+	}}
+
+	got := task.TimeSegments(time.Time{}, time.Time{}, now)
+	want := []TimeEntry{
+		{Start: at(8, 22), End: at(9, 0)},
+		{Start: at(9, 0), End: at(10, 0)},
+		{Start: at(10, 0), End: at(10, 2)},
+		{Start: at(12, 10), End: now},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("TimeSegments() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if !got[i].Start.Equal(want[i].Start) || !got[i].End.Equal(want[i].End) {
+			t.Errorf("segment %d = %v-%v, want %v-%v", i, got[i].Start, got[i].End, want[i].Start, want[i].End)
+		}
+	}
+
+	clipped := task.TimeSegments(at(9, 12), at(10, 0), now)
+	if len(clipped) != 1 || !clipped[0].Start.Equal(at(9, 12)) || !clipped[0].End.Equal(at(10, 0)) {
+		t.Errorf("Expected the range to clip segments, got %+v", clipped)
+	}
+}
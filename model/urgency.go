@@ -0,0 +1,159 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// UrgencyCoefficients weight the factors that make up a task's urgency. Each
+// factor is a value between 0 and 1 that is multiplied by its coefficient;
+// the urgency is the sum of the products. A coefficient of zero disables a
+// factor and a negative one lowers the urgency of matching tasks.
+type UrgencyCoefficients struct {
+	// Priority weighs the priority: 1 for high, 0.65 for medium and 0.3 for low.
+	Priority float64 `json:"priority"`
+	// Due weighs how close the due date is, from 0.2 two weeks or more ahead
+	// up to 1 a week or more after it.
+	Due float64 `json:"due"`
+	// Overdue is added to tasks whose due date has passed.
+	Overdue float64 `json:"overdue"`
+	// Age weighs the time since the task was created, reaching 1 at MaxAgeDays.
+	Age        float64 `json:"age"`
+	MaxAgeDays int     `json:"max_age_days"`
+	// Tags weighs having tags at all: 0.8 for one, 0.9 for two, 1 for more.
+	Tags float64 `json:"tags"`
+	// Tag adds a coefficient for each tag the task carries, such as "next".
+	Tag map[string]float64 `json:"tag"`
+	// Blocked is added to tasks with the blocked status or waiting on an
+	// incomplete dependency.
+	Blocked float64 `json:"blocked"`
+}
+
+// DefaultUrgencyCoefficients returns the coefficients used unless the
+// configuration sets others.
+func DefaultUrgencyCoefficients() UrgencyCoefficients {
+	return UrgencyCoefficients{
+		Priority:   6,
+		Due:        12,
+		Overdue:    2,
+		Age:        2,
+		MaxAgeDays: 365,
+		Tags:       1,
+		Tag:        map[string]float64{"next": 15},
+		Blocked:    -5,
+	}
+}
+
+// UrgencyTerm is the contribution of one factor to a task's urgency.
+type UrgencyTerm struct {
+	// Factor names the factor, such as "due" or "tag +next".
+	Factor string
+	// Detail describes the task's value for the factor, such as "in 3 days".
+	Detail      string
+	Value       float64
+	Coefficient float64
+}
+
+// Score is the term's contribution to the urgency.
+func (u UrgencyTerm) Score() float64 {
+	return u.Value * u.Coefficient
+}
+
+// Urgency returns how urgent the task is at now, the sum of its urgency
+// terms. Done and cancelled tasks have no urgency.
+func (t *Task) Urgency(c UrgencyCoefficients, lookup TaskLookup, now time.Time) float64 {
+	var urgency float64
+	for _, term := range t.UrgencyTerms(c, lookup, now) {
+		urgency += term.Score()
+	}
+	return urgency
+}
+
+// UrgencyTerms breaks the task's urgency at now down into the factors that
+// apply to it. Factors with a zero value or coefficient are left out.
+func (t *Task) UrgencyTerms(c UrgencyCoefficients, lookup TaskLookup, now time.Time) []UrgencyTerm {
+	if t.IsClosed() {
+		return nil
+	}
+
+	var terms []UrgencyTerm
+	add := func(factor, detail string, value, coefficient float64) {
+		if value != 0 && coefficient != 0 {
+			terms = append(terms, UrgencyTerm{Factor: factor, Detail: detail, Value: value, Coefficient: coefficient})
+		}
+	}
+
+	switch t.Priority {
+	case High:
+		add("priority", "high", 1, c.Priority)
+	case Medium:
+		add("priority", "medium", 0.65, c.Priority)
+	default:
+		add("priority", "low", 0.3, c.Priority)
+	}
+
+	if !t.DueDate.IsZero() {
+		days := now.Sub(t.deadline()).Hours() / 24
+		detail := fmt.Sprintf("in %s", formatDays(-days))
+		if days >= 0 {
+			detail = fmt.Sprintf("%s ago", formatDays(days))
+		}
+		add("due", detail, dueProximity(days), c.Due)
+		if t.isOverdueAt(now) {
+			add("overdue", "", 1, c.Overdue)
+		}
+	}
+
+	if !t.CreatedAt.IsZero() && c.MaxAgeDays > 0 {
+		days := max(now.Sub(t.CreatedAt).Hours()/24, 0)
+		add("age", formatDays(days), math.Min(days/float64(c.MaxAgeDays), 1), c.Age)
+	}
+
+	switch n := len(t.Tags); {
+	case n == 1:
+		add("tags", "1 tag", 0.8, c.Tags)
+	case n == 2:
+		add("tags", "2 tags", 0.9, c.Tags)
+	case n > 2:
+		add("tags", fmt.Sprintf("%d tags", n), 1, c.Tags)
+	}
+	tagCoefficients := make(map[string]float64, len(c.Tag))
+	for tag, coefficient := range c.Tag {
+		tagCoefficients[NormalizeTag(tag)] += coefficient
+	}
+	for _, tag := range t.Tags {
+		add("tag +"+tag, "", 1, tagCoefficients[tag])
+	}
+
+	if t.State() == StatusBlocked || t.IsBlocked(lookup) {
+		add("blocked", "", 1, c.Blocked)
+	}
+	return terms
+}
+
+// dueProximity maps the days since a task's deadline, negative while it is
+// still ahead, to a value that grows linearly from 0.2 two weeks before the
+// deadline to 1 a week after it.
+func dueProximity(days float64) float64 {
+	switch {
+	case days >= 7:
+		return 1
+	case days >= -14:
+		return (days+14)*0.8/21 + 0.2
+	default:
+		return 0.2
+	}
+}
+
+// formatDays renders a number of days for an urgency explanation.
+func formatDays(days float64) string {
+	n, unit := math.Round(days), "day"
+	if days < 1 {
+		n, unit = math.Round(days*24), "hour"
+	}
+	if n != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%.0f %s", n, unit)
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTask_Urgency(t *testing.T) {
+	now := date(2025, 6, 2)
+	c := DefaultUrgencyCoefficients()
+	dependency := &Task{ID: 9, Title: "Dependency", CreatedAt: now}
+	lookup := func(id int) *Task {
+		if id == dependency.ID {
+			return dependency
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name string
+		task Task
+		want float64
+	}{
+		{"low priority", Task{Priority: Low, CreatedAt: now}, 0.3 * 6},
+		{"high priority", Task{Priority: High, CreatedAt: now}, 6},
+		// Due in 14 days or more counts as 0.2, a week overdue as 1.
+		{"due far ahead", Task{Priority: Low, CreatedAt: now, DueDate: now.AddDate(0, 1, 0)}, 1.8 + 0.2*12},
+		{"due long ago", Task{Priority: Low, CreatedAt: now, DueDate: now.AddDate(0, 0, -10)}, 1.8 + 12 + 2},
+		{"half a year old", Task{Priority: Low, CreatedAt: now.AddDate(0, 0, -73)}, 1.8 + 0.2*2},
+		{"tagged next", Task{Priority: Low, CreatedAt: now, Tags: []string{"next"}}, 1.8 + 0.8 + 15},
+		{"blocked", Task{Priority: Low, CreatedAt: now, DependsOn: []int{9}}, 1.8 - 5},
+		{"blocked status", Task{Priority: Low, CreatedAt: now, Status: StatusBlocked}, 1.8 - 5},
+		{"done", Task{Priority: High, CreatedAt: now, Status: StatusDone, CompletedAt: now}, 0},
+		{"cancelled", Task{Priority: High, CreatedAt: now, Status: StatusCancelled, CompletedAt: now}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.task.Urgency(c, lookup, now)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Urgency() = %v, want %v (terms %+v)", got, tt.want, tt.task.UrgencyTerms(c, lookup, now))
+			}
+		})
+	}
+}
+
+func TestTask_UrgencyTerms(t *testing.T) {
+	now := date(2025, 6, 2)
+	c := DefaultUrgencyCoefficients()
+	c.Tags = 0
+	task := Task{Priority: Medium, CreatedAt: now, DueDate: now.Add(3 * 24 * time.Hour), Tags: []string{"home"}}
+
+	terms := task.UrgencyTerms(c, nil, now)
+	if len(terms) != 2 || terms[0].Factor != "priority" || terms[1].Factor != "due" {
+		t.Fatalf("Expected priority and due terms without zero coefficients, got %+v", terms)
+	}
+	if terms[1].Detail != "in 3 days" {
+		t.Errorf("Expected due detail %q, got %q", "in 3 days", terms[1].Detail)
+	}
+	if want := (11.0*0.8/21 + 0.2) * 12; math.Abs(terms[1].Score()-want) > 1e-9 {
+		t.Errorf("Expected due score %v, got %v", want, terms[1].Score())
+	}
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// ShortUUIDLength is the number of leading UUID characters shown where space
+// is short. It is also the shortest UUID prefix accepted in place of an ID.
+const ShortUUIDLength = 8
+
+// NewUUID returns a random (version 4) UUID in its canonical form.
+func NewUUID() string {
+	var b [16]byte
+	// rand.Read never fails; it crashes the program if the OS has no entropy.
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// ShortUUID returns the first ShortUUIDLength characters of the task's UUID.
+func (t *Task) ShortUUID() string {
+	if len(t.UUID) <= ShortUUIDLength {
+		return t.UUID
+	}
+	return t.UUID[:ShortUUIDLength]
+}
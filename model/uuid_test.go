@@ -0,0 +1,26 @@
+package model
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestNewUUID(t *testing.T) {
+	format := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		uuid := NewUUID()
+		if !format.MatchString(uuid) {
+			t.Fatalf("NewUUID() = %q, want a version 4 UUID", uuid)
+		}
+		if seen[uuid] {
+			t.Fatalf("NewUUID() returned %q twice", uuid)
+		}
+		seen[uuid] = true
+	}
+
+	task := &Task{UUID: "0f8fad5b-d9cb-469f-a165-70867728950e"}
+	if got := task.ShortUUID(); got != "0f8fad5b" {
+		t.Errorf("ShortUUID() = %q, want 0f8fad5b", got)
+	}
+}
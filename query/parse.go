@@ -0,0 +1,259 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kevin7254/task/model"
+)
+
+// token is a word or parenthesis of a query. Quotes are removed from text;
+// literal records whether the word started with a quote, so that a quoted
+// "and" or "+tag" is searched for as text.
+type token struct {
+	text    string
+	literal bool
+}
+
+// lex splits input into tokens at whitespace and parentheses outside quotes.
+func lex(input string) ([]token, error) {
+	var (
+		tokens  []token
+		current strings.Builder
+		inWord  bool
+		literal bool
+		quote   rune
+	)
+	flush := func() {
+		if inWord {
+			tokens = append(tokens, token{text: current.String(), literal: literal})
+		}
+		current.Reset()
+		inWord, literal = false, false
+	}
+
+	runes := []rune(input)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			if r == '\\' && i+1 < len(runes) && (runes[i+1] == quote || runes[i+1] == '\\') {
+				i++
+				current.WriteRune(runes[i])
+			} else if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			literal = literal || !inWord
+			quote, inWord = r, true
+		case r == '(' || r == ')':
+			flush()
+			tokens = append(tokens, token{text: string(r)})
+		case r == ' ' || r == '\t' || r == '\n':
+			flush()
+		default:
+			inWord = true
+			current.WriteRune(r)
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in query")
+	}
+	flush()
+	return tokens, nil
+}
+
+// conditionPattern matches field conditions such as "project:work",
+// "priority>=2" and "due.before:fri".
+var conditionPattern = regexp.MustCompile(`^([a-zA-Z_]+)(?:\.([a-zA-Z]+))?(!=|<=|>=|!~|[:=<>~])(.*)$`)
+
+// modifiers maps the attribute modifiers of "field.modifier:value" to operators.
+var modifiers = map[string]Op{
+	"is":         Eq,
+	"equals":     Eq,
+	"isnt":       Ne,
+	"not":        Ne,
+	"before":     Lt,
+	"below":      Lt,
+	"after":      Gt,
+	"above":      Gt,
+	"has":        Has,
+	"contains":   Has,
+	"hasnt":      HasNot,
+	"startswith": StartsWith,
+	"endswith":   EndsWith,
+}
+
+// operators maps the infix operators of "field<op>value" to operators.
+var operators = map[string]Op{
+	":":  Eq,
+	"=":  Eq,
+	"!=": Ne,
+	"<":  Lt,
+	"<=": Le,
+	">":  Gt,
+	">=": Ge,
+	"~":  Has,
+	"!~": HasNot,
+}
+
+// Parse parses a query expression. Relative dates such as "fri" are resolved
+// against now. Terms are combined with "and" (which may be omitted), "or"
+// and "not", in that order of precedence, and grouped with parentheses:
+//
+//	project:work priority>=2      field conditions
+//	due.before:fri                 field conditions with a modifier
+//	title~"deploy"                 substring match
+//	+review -blocked               has / has not tag
+//	42                             task ID
+//	deploy                         text in the title or description
+func Parse(input string, now time.Time) (Expr, error) {
+	tokens, lexErr := lex(input)
+	if lexErr != nil {
+		return nil, lexErr
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+
+	p := &parser{tokens: tokens, now: now}
+	expr, parseErr := p.parseOr()
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	if tok, ok := p.peek(); ok {
+		return nil, fmt.Errorf("unexpected %q in query", tok.text)
+	}
+	return expr, nil
+}
+
+// parser is a recursive descent parser over the tokens of a query.
+type parser struct {
+	tokens []token
+	pos    int
+	now    time.Time
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+// peekKeyword reports whether the next token is the given unquoted keyword.
+func (p *parser) peekKeyword(keyword string) bool {
+	tok, ok := p.peek()
+	return ok && !tok.literal && strings.EqualFold(tok.text, keyword)
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok, ok := p.peek()
+		if !ok || (!tok.literal && tok.text == ")") || p.peekKeyword("or") {
+			return left, nil
+		}
+		if p.peekKeyword("and") {
+			p.pos++
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &And{Left: left, Right: right}
+	}
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("query ends where a term was expected")
+	}
+	p.pos++
+
+	switch {
+	case tok.literal:
+		return &Text{Value: tok.text}, nil
+	case strings.EqualFold(tok.text, "not"):
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Expr: expr}, nil
+	case tok.text == "(":
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing, ok := p.peek(); !ok || closing.literal || closing.text != ")" {
+			return nil, fmt.Errorf("missing ) in query")
+		}
+		p.pos++
+		return expr, nil
+	case tok.text == ")":
+		return nil, fmt.Errorf("unexpected ) in query")
+	case strings.EqualFold(tok.text, "and"), strings.EqualFold(tok.text, "or"):
+		return nil, fmt.Errorf("%q must be between two terms", tok.text)
+	default:
+		return p.parseTerm(tok)
+	}
+}
+
+// parseTerm parses a single unquoted tag, field condition, ID or text term.
+func (p *parser) parseTerm(tok token) (Expr, error) {
+	text := tok.text
+	switch {
+	case len(text) > 1 && text[0] == '+':
+		return &Tag{Name: model.NormalizeTag(text)}, nil
+	case len(text) > 1 && text[0] == '-':
+		return &Not{Expr: &Tag{Name: model.NormalizeTag(text[1:])}}, nil
+	}
+
+	if m := conditionPattern.FindStringSubmatch(text); m != nil {
+		return p.parseCondition(m[1], m[2], m[3], m[4])
+	}
+	if _, err := strconv.Atoi(text); err == nil {
+		return newCondition("id", Eq, text, p.now)
+	}
+	return &Text{Value: text}, nil
+}
+
+func (p *parser) parseCondition(field, modifier, operator, value string) (Expr, error) {
+	op := operators[operator]
+	if modifier != "" {
+		modOp, ok := modifiers[strings.ToLower(modifier)]
+		if !ok {
+			return nil, fmt.Errorf("unknown modifier %q in %s.%s", modifier, field, modifier)
+		}
+		if op != Eq {
+			return nil, fmt.Errorf("use %s.%s:value, not %s.%s%svalue", field, modifier, field, modifier, operator)
+		}
+		op = modOp
+	}
+	return newCondition(strings.ToLower(field), op, value, p.now)
+}
@@ -0,0 +1,465 @@
+// Package query implements the filter language used to select tasks, such as
+//
+//	project:work priority>=2 due.before:fri +review -blocked title~"deploy"
+//
+// Expressions are parsed into a tree of Expr nodes that are evaluated
+// against a model.Task.
+package query
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kevin7254/task/dateparse"
+	"github.com/kevin7254/task/model"
+)
+
+// Expr is a node of a parsed query.
+type Expr interface {
+	// Match reports whether task satisfies the expression. lookup resolves
+	// related tasks, for example to decide whether a task is blocked.
+	Match(task *model.Task, lookup model.TaskLookup) bool
+	// String returns the expression in canonical, fully parenthesized form.
+	String() string
+}
+
+// And matches tasks that match both Left and Right.
+type And struct{ Left, Right Expr }
+
+// Or matches tasks that match Left, Right or both.
+type Or struct{ Left, Right Expr }
+
+// Not matches tasks that do not match Expr.
+type Not struct{ Expr Expr }
+
+// Tag matches tasks that carry the tag Name.
+type Tag struct{ Name string }
+
+// Text matches tasks whose title, description or an annotation contains
+// Value, ignoring case.
+type Text struct{ Value string }
+
+func (e *And) Match(task *model.Task, lookup model.TaskLookup) bool {
+	return e.Left.Match(task, lookup) && e.Right.Match(task, lookup)
+}
+
+func (e *And) String() string { return "(" + e.Left.String() + " and " + e.Right.String() + ")" }
+
+func (e *Or) Match(task *model.Task, lookup model.TaskLookup) bool {
+	return e.Left.Match(task, lookup) || e.Right.Match(task, lookup)
+}
+
+func (e *Or) String() string { return "(" + e.Left.String() + " or " + e.Right.String() + ")" }
+
+func (e *Not) Match(task *model.Task, lookup model.TaskLookup) bool {
+	return !e.Expr.Match(task, lookup)
+}
+
+func (e *Not) String() string { return "not " + e.Expr.String() }
+
+func (e *Tag) Match(task *model.Task, _ model.TaskLookup) bool { return task.HasTag(e.Name) }
+
+func (e *Tag) String() string { return "+" + e.Name }
+
+func (e *Text) Match(task *model.Task, _ model.TaskLookup) bool {
+	if containsFold(task.Title, e.Value) || containsFold(task.Description, e.Value) {
+		return true
+	}
+	return slices.ContainsFunc(task.Annotations, func(a model.Annotation) bool {
+		return containsFold(a.Description, e.Value)
+	})
+}
+
+func (e *Text) String() string { return strconv.Quote(e.Value) }
+
+// Op is a comparison operator of a field condition.
+type Op string
+
+const (
+	Eq         Op = "="
+	Ne         Op = "!="
+	Lt         Op = "<"
+	Le         Op = "<="
+	Gt         Op = ">"
+	Ge         Op = ">="
+	Has        Op = "~"
+	HasNot     Op = "!~"
+	StartsWith Op = "^="
+	EndsWith   Op = "$="
+)
+
+// Condition compares a task field with a value, e.g. "priority >= 2".
+type Condition struct {
+	Field string
+	Op    Op
+	Value string
+	match func(task *model.Task, lookup model.TaskLookup) bool
+}
+
+func (c *Condition) Match(task *model.Task, lookup model.TaskLookup) bool {
+	return c.match(task, lookup)
+}
+
+func (c *Condition) String() string {
+	return c.Field + " " + string(c.Op) + " " + strconv.Quote(c.Value)
+}
+
+// References reports whether expr contains a condition on field.
+func References(expr Expr, field string) bool {
+	switch e := expr.(type) {
+	case *And:
+		return References(e.Left, field) || References(e.Right, field)
+	case *Or:
+		return References(e.Left, field) || References(e.Right, field)
+	case *Not:
+		return References(e.Expr, field)
+	case *Condition:
+		return e.Field == field
+	default:
+		return false
+	}
+}
+
+// Filter returns the tasks that match expr, in their original order.
+func Filter(tasks []*model.Task, expr Expr, lookup model.TaskLookup) []*model.Task {
+	matched := make([]*model.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if expr.Match(task, lookup) {
+			matched = append(matched, task)
+		}
+	}
+	return matched
+}
+
+// Statuses are the values accepted by the status field: the workflow
+// statuses of model.Statuses followed by the derived ones.
+var Statuses = []string{
+	"pending", "in-progress", "waiting", "blocked", "done", "cancelled",
+	"open", "overdue", "ready", "active",
+}
+
+// fieldCompiler builds the matcher for a condition on one field.
+type fieldCompiler func(op Op, value string, now time.Time) (func(*model.Task, model.TaskLookup) bool, error)
+
+// fields lists the task fields that conditions can test.
+var fields = map[string]fieldCompiler{
+	"id":          intField(func(t *model.Task) int { return t.ID }, nil),
+	"uuid":        stringField(func(t *model.Task) string { return t.UUID }),
+	"title":       stringField(func(t *model.Task) string { return t.Title }),
+	"description": stringField(func(t *model.Task) string { return t.Description }),
+	"project":     stringField(func(t *model.Task) string { return t.Project }),
+	"priority":    intField(func(t *model.Task) int { return int(t.Priority) }, parsePriority),
+	"due":         dateField(func(t *model.Task) time.Time { return t.DueDate }),
+	"created":     dateField(func(t *model.Task) time.Time { return t.CreatedAt }),
+	"completed":   dateField(func(t *model.Task) time.Time { return t.CompletedAt }),
+	"tag":         tagField,
+	"parent":      intField(func(t *model.Task) int { return t.ParentID }, parseNone),
+	"depends":     dependsField,
+	"recur": stringField(func(t *model.Task) string {
+		if t.Recur == nil {
+			return ""
+		}
+		return t.Recur.String()
+	}),
+	"status":     statusField,
+	"annotation": annotationField,
+}
+
+// aliases maps alternative field names to the names in fields.
+var aliases = map[string]string{
+	"desc":        "description",
+	"pro":         "project",
+	"pri":         "priority",
+	"tags":        "tag",
+	"done":        "completed",
+	"end":         "completed",
+	"entry":       "created",
+	"state":       "status",
+	"depends_on":  "depends",
+	"annotations": "annotation",
+	"note":        "annotation",
+}
+
+// newCondition compiles the condition "field op value".
+func newCondition(field string, op Op, value string, now time.Time) (*Condition, error) {
+	if canonical, ok := aliases[field]; ok {
+		field = canonical
+	}
+	compile, ok := fields[field]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q in query", field)
+	}
+	match, err := compile(op, value, now)
+	if err != nil {
+		return nil, fmt.Errorf("%s%s%s: %w", field, op, value, err)
+	}
+	return &Condition{Field: field, Op: op, Value: value, match: match}, nil
+}
+
+func unsupported(op Op) error {
+	return fmt.Errorf("operator %s is not supported for this field", op)
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// stringField compares text case-insensitively; "field:none" matches an
+// empty value.
+func stringField(get func(*model.Task) string) fieldCompiler {
+	return func(op Op, value string, _ time.Time) (func(*model.Task, model.TaskLookup) bool, error) {
+		if (op == Eq || op == Ne) && dateparse.IsNone(value) {
+			value = ""
+		}
+		test, err := stringTest(op, value)
+		if err != nil {
+			return nil, err
+		}
+		return func(t *model.Task, _ model.TaskLookup) bool { return test(get(t)) }, nil
+	}
+}
+
+// stringTest returns the case-insensitive test of a string against value.
+func stringTest(op Op, value string) (func(s string) bool, error) {
+	var test func(s string) bool
+	switch op {
+	case Eq:
+		test = func(s string) bool { return strings.EqualFold(s, value) }
+	case Ne:
+		test = func(s string) bool { return !strings.EqualFold(s, value) }
+	case Has:
+		test = func(s string) bool { return containsFold(s, value) }
+	case HasNot:
+		test = func(s string) bool { return !containsFold(s, value) }
+	case StartsWith:
+		test = func(s string) bool { return strings.HasPrefix(strings.ToLower(s), strings.ToLower(value)) }
+	case EndsWith:
+		test = func(s string) bool { return strings.HasSuffix(strings.ToLower(s), strings.ToLower(value)) }
+	default:
+		return nil, unsupported(op)
+	}
+	return test, nil
+}
+
+// annotationField tests the text of a task's annotations. = and ~ match if
+// any annotation matches, != and !~ if none does; "none" matches tasks
+// without annotations.
+func annotationField(op Op, value string, _ time.Time) (func(*model.Task, model.TaskLookup) bool, error) {
+	if dateparse.IsNone(value) {
+		switch op {
+		case Eq:
+			return func(t *model.Task, _ model.TaskLookup) bool { return len(t.Annotations) == 0 }, nil
+		case Ne:
+			return func(t *model.Task, _ model.TaskLookup) bool { return len(t.Annotations) > 0 }, nil
+		}
+	}
+
+	negate := false
+	switch op {
+	case Ne:
+		op, negate = Eq, true
+	case HasNot:
+		op, negate = Has, true
+	}
+	test, err := stringTest(op, value)
+	if err != nil {
+		return nil, err
+	}
+	return func(t *model.Task, _ model.TaskLookup) bool {
+		matched := slices.ContainsFunc(t.Annotations, func(a model.Annotation) bool { return test(a.Description) })
+		return matched != negate
+	}, nil
+}
+
+// intField compares numbers. parse, if set, accepts values other than
+// plain integers.
+func intField(get func(*model.Task) int, parse func(string) (int, bool)) fieldCompiler {
+	return func(op Op, value string, _ time.Time) (func(*model.Task, model.TaskLookup) bool, error) {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			parsed, ok := 0, false
+			if parse != nil {
+				parsed, ok = parse(value)
+			}
+			if !ok {
+				return nil, fmt.Errorf("invalid number %q", value)
+			}
+			n = parsed
+		}
+
+		var test func(v int) bool
+		switch op {
+		case Eq:
+			test = func(v int) bool { return v == n }
+		case Ne:
+			test = func(v int) bool { return v != n }
+		case Lt:
+			test = func(v int) bool { return v < n }
+		case Le:
+			test = func(v int) bool { return v <= n }
+		case Gt:
+			test = func(v int) bool { return v > n }
+		case Ge:
+			test = func(v int) bool { return v >= n }
+		default:
+			return nil, unsupported(op)
+		}
+		return func(t *model.Task, _ model.TaskLookup) bool { return test(get(t)) }, nil
+	}
+}
+
+// parsePriority accepts priority names and their initials.
+func parsePriority(value string) (int, bool) {
+	switch strings.ToLower(value) {
+	case "l", "low":
+		return int(model.Low), true
+	case "m", "medium":
+		return int(model.Medium), true
+	case "h", "high":
+		return int(model.High), true
+	}
+	return 0, false
+}
+
+// parseNone accepts "none" for a zero reference.
+func parseNone(value string) (int, bool) {
+	return 0, dateparse.IsNone(value)
+}
+
+// dateField compares dates. A value without a time of day stands for the
+// whole day, so "due:fri" matches any time on Friday and "due.after:fri"
+// starts on Saturday. Unset dates only match "none" and "!=".
+func dateField(get func(*model.Task) time.Time) fieldCompiler {
+	return func(op Op, value string, now time.Time) (func(*model.Task, model.TaskLookup) bool, error) {
+		if dateparse.IsNone(value) {
+			switch op {
+			case Eq:
+				return func(t *model.Task, _ model.TaskLookup) bool { return get(t).IsZero() }, nil
+			case Ne:
+				return func(t *model.Task, _ model.TaskLookup) bool { return !get(t).IsZero() }, nil
+			default:
+				return nil, unsupported(op)
+			}
+		}
+
+		start, err := dateparse.Parse(value, now)
+		if err != nil {
+			return nil, err
+		}
+		end := start
+		if dateparse.IsDateOnly(start) {
+			end = start.AddDate(0, 0, 1)
+		}
+		within := func(d time.Time) bool {
+			if end.Equal(start) {
+				return d.Equal(start)
+			}
+			return !d.Before(start) && d.Before(end)
+		}
+
+		var test func(d time.Time) bool
+		switch op {
+		case Eq:
+			test = within
+		case Ne:
+			return func(t *model.Task, _ model.TaskLookup) bool { return !within(get(t)) }, nil
+		case Lt:
+			test = func(d time.Time) bool { return d.Before(start) }
+		case Le:
+			test = func(d time.Time) bool { return within(d) || d.Before(start) }
+		case Gt:
+			test = func(d time.Time) bool { return !within(d) && !d.Before(start) }
+		case Ge:
+			test = func(d time.Time) bool { return !d.Before(start) }
+		default:
+			return nil, unsupported(op)
+		}
+		return func(t *model.Task, _ model.TaskLookup) bool {
+			d := get(t)
+			return !d.IsZero() && test(d)
+		}, nil
+	}
+}
+
+// tagField tests whether a task carries a tag; "none" matches untagged tasks.
+func tagField(op Op, value string, _ time.Time) (func(*model.Task, model.TaskLookup) bool, error) {
+	var has func(t *model.Task) bool
+	if dateparse.IsNone(value) {
+		has = func(t *model.Task) bool { return len(t.Tags) == 0 }
+	} else {
+		has = func(t *model.Task) bool { return t.HasTag(value) }
+	}
+	switch op {
+	case Eq, Has:
+		return func(t *model.Task, _ model.TaskLookup) bool { return has(t) }, nil
+	case Ne, HasNot:
+		return func(t *model.Task, _ model.TaskLookup) bool { return !has(t) }, nil
+	default:
+		return nil, unsupported(op)
+	}
+}
+
+// dependsField tests whether a task depends on a task ID; "none" matches
+// tasks without dependencies.
+func dependsField(op Op, value string, _ time.Time) (func(*model.Task, model.TaskLookup) bool, error) {
+	var has func(t *model.Task) bool
+	if dateparse.IsNone(value) {
+		has = func(t *model.Task) bool { return len(t.DependsOn) == 0 }
+	} else {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid task ID %q", value)
+		}
+		has = func(t *model.Task) bool { return slices.Contains(t.DependsOn, id) }
+	}
+	switch op {
+	case Eq, Has:
+		return func(t *model.Task, _ model.TaskLookup) bool { return has(t) }, nil
+	case Ne, HasNot:
+		return func(t *model.Task, _ model.TaskLookup) bool { return !has(t) }, nil
+	default:
+		return nil, unsupported(op)
+	}
+}
+
+// statusField tests the status of a task. Besides the workflow statuses it
+// accepts open (not done or cancelled), overdue, ready (pending or in
+// progress and not blocked) and active (time is being tracked). blocked
+// matches both tasks marked blocked and open tasks waiting on a dependency.
+func statusField(op Op, value string, _ time.Time) (func(*model.Task, model.TaskLookup) bool, error) {
+	var is func(t *model.Task, lookup model.TaskLookup) bool
+	switch strings.ToLower(value) {
+	case "blocked":
+		is = func(t *model.Task, lookup model.TaskLookup) bool {
+			return t.State() == model.StatusBlocked || (!t.IsClosed() && t.IsBlocked(lookup))
+		}
+	case "open":
+		is = func(t *model.Task, _ model.TaskLookup) bool { return !t.IsClosed() }
+	case "overdue":
+		is = func(t *model.Task, _ model.TaskLookup) bool { return !t.IsClosed() && t.IsOverdue() }
+	case "ready":
+		is = func(t *model.Task, lookup model.TaskLookup) bool {
+			state := t.State()
+			return (state == model.StatusPending || state == model.StatusInProgress) && !t.IsBlocked(lookup)
+		}
+	case "active":
+		is = func(t *model.Task, _ model.TaskLookup) bool { return t.IsActive() }
+	default:
+		status, err := model.ParseStatus(value)
+		if err != nil {
+			return nil, fmt.Errorf("unknown status %q (expected one of %s)", value, strings.Join(Statuses, ", "))
+		}
+		is = func(t *model.Task, _ model.TaskLookup) bool { return t.State() == status }
+	}
+	switch op {
+	case Eq:
+		return is, nil
+	case Ne:
+		return func(t *model.Task, lookup model.TaskLookup) bool { return !is(t, lookup) }, nil
+	default:
+		return nil, unsupported(op)
+	}
+}
@@ -0,0 +1,151 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kevin7254/task/model"
+)
+
+// Wednesday afternoon.
+var now = time.Date(2025, 6, 4, 15, 30, 0, 0, time.UTC)
+
+func day(d int) time.Time {
+	return time.Date(2025, 6, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParse_String(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"project:work", `project = "work"`},
+		{"project:work priority>=2", `(project = "work" and priority >= "2")`},
+		{"+review -blocked", `(+review and not +blocked)`},
+		{`title~"deploy now"`, `title ~ "deploy now"`},
+		{"due.before:fri", `due < "fri"`},
+		{"a or b and c", `("a" or ("b" and "c"))`},
+		{"(a or b) c", `(("a" or "b") and "c")`},
+		{"not +x or pri:h", `(not +x or priority = "h")`},
+		{`"and" 42`, `("and" and id = "42")`},
+	}
+	for _, tt := range tests {
+		expr, err := Parse(tt.input, now)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if got := expr.String(); got != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"(project:work",
+		"project:work)",
+		"foo:bar",
+		"due.soon:fri",
+		"priority~2",
+		"priority>=urgent",
+		"status:sleeping",
+		"due.before:someday",
+		`title~"open`,
+		"a and",
+		"or b",
+	} {
+		if expr, err := Parse(input, now); err == nil {
+			t.Errorf("Parse(%q) = %s, want an error", input, expr)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	dep := &model.Task{ID: 1, Title: "Dependency"}
+	lookup := func(id int) *model.Task {
+		if id == dep.ID {
+			return dep
+		}
+		return nil
+	}
+	task := &model.Task{
+		ID:          7,
+		Title:       "Deploy the API",
+		Description: "Needs a review",
+		Project:     "Work",
+		Priority:    model.Medium,
+		DueDate:     day(6).Add(14 * time.Hour), // Friday afternoon
+		Tags:        []string{"review"},
+		DependsOn:   []int{1},
+		Annotations: []model.Annotation{{Entry: day(2), Description: "Asked ops for a slot"}},
+	}
+
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"project:work", true},
+		{"project:home", false},
+		{"project!=home", true},
+		{"priority>=2", true},
+		{"priority>medium", false},
+		{"pri:m", true},
+		{"+review", true},
+		{"-review", false},
+		{"tag:none", false},
+		{`title~"deploy"`, true},
+		{"title.startswith:deploy", true},
+		{"title.endswith:deploy", false},
+		{"api", true},
+		{"review", true},
+		{"due:fri", true},
+		{"due.before:fri", false},
+		{"due.before:sat", true},
+		{"due.after:thu", true},
+		{"due.after:fri", false},
+		{"due<=fri", true},
+		{"due:none", false},
+		{"completed:none", true},
+		{"status:pending", true},
+		{"status:blocked", true},
+		{"status:ready", false},
+		{"depends:1", true},
+		{"annotation~ops", true},
+		{"annotation!~ops", false},
+		{"annotation!=slot", true},
+		{"annotation:none", false},
+		{"note.startswith:asked", true},
+		{"slot", true},
+		{"7", true},
+		{"8", false},
+		{"project:work priority>=2 due.before:sat +review -blocked title~deploy", true},
+		{"project:home or +review", true},
+		{"not (project:home or +urgent)", true},
+		{"project:home and +review", false},
+	}
+	for _, tt := range tests {
+		expr, err := Parse(tt.input, now)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if got := expr.Match(task, lookup); got != tt.want {
+			t.Errorf("%s matched %v, want %v", expr, got, tt.want)
+		}
+	}
+}
+
+func TestReferences(t *testing.T) {
+	expr, err := Parse("+x or not status:completed", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !References(expr, "status") {
+		t.Error("Expected the query to reference status")
+	}
+	if References(expr, "project") {
+		t.Error("Expected the query not to reference project")
+	}
+}
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// writeFileAtomic writes data to filename so that readers only ever observe
+// either the previous contents or the complete new contents. The data is
+// written to a temporary file in the same directory, flushed to disk and then
+// renamed over the target.
+func writeFileAtomic(filename string, data []byte, perm os.FileMode) (err error) {
+	dir := filepath.Dir(filename)
+	tmp, osErr := os.CreateTemp(dir, "."+filepath.Base(filename)+".tmp-*")
+	if osErr != nil {
+		return fmt.Errorf("failed to create temp file: %w", osErr)
+	}
+	tmpName := tmp.Name()
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmpName)
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	if err = tmp.Chmod(perm); err != nil {
+		return fmt.Errorf("failed to set permissions on temp file: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+	if err = os.Rename(tmpName, filename); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", filename, err)
+	}
+
+	syncDir(dir)
+	return nil
+}
+
+// syncDir flushes directory metadata so a completed rename survives a crash.
+// Not every platform supports syncing directories, so failures are ignored.
+func syncDir(dir string) {
+	d, osErr := os.Open(dir)
+	if osErr != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
@@ -0,0 +1,20 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/kevin7254/task/store"
+	"github.com/kevin7254/task/store/storetest"
+)
+
+func TestJsonStore_Conformance(t *testing.T) {
+	storetest.Run(t, func(filename string) (store.TaskRepository, error) {
+		return store.NewJsonStore(filename + ".json")
+	})
+}
+
+func TestSQLiteStore_Conformance(t *testing.T) {
+	storetest.Run(t, func(filename string) (store.TaskRepository, error) {
+		return store.NewSQLiteStore(filename + ".db")
+	})
+}
@@ -0,0 +1,43 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrLocked is returned when the store's lock could not be acquired because
+// another process held it for longer than the configured timeout.
+var ErrLocked = errors.New("task store is locked by another process")
+
+// DefaultLockTimeout is how long a store waits for another process to release
+// its lock before giving up.
+const DefaultLockTimeout = 5 * time.Second
+
+// lockRetryInterval is how often a contended lock is retried.
+const lockRetryInterval = 10 * time.Millisecond
+
+// fileLock is an advisory, cross-process lock backed by a lock file.
+type fileLock struct {
+	f *os.File
+}
+
+// acquireFileLock takes the exclusive lock on filename, waiting up to timeout
+// for a competing process to release it.
+func acquireFileLock(filename string, timeout time.Duration) (*fileLock, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		lock, acquired, lockErr := tryLockFile(filename)
+		if lockErr != nil {
+			return nil, fmt.Errorf("failed to lock %s: %w", filename, lockErr)
+		}
+		if acquired {
+			return lock, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w: gave up waiting for %s after %s", ErrLocked, filename, timeout)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
@@ -0,0 +1,30 @@
+//go:build !unix
+
+package store
+
+import (
+	"errors"
+	"os"
+)
+
+// tryLockFile attempts to take the lock by exclusively creating filename.
+// Platforms without flock fall back to the lock file's existence.
+func tryLockFile(filename string) (*fileLock, bool, error) {
+	f, osErr := os.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0644)
+	if osErr != nil {
+		if errors.Is(osErr, os.ErrExist) {
+			return nil, false, nil
+		}
+		return nil, false, osErr
+	}
+	return &fileLock{f: f}, true, nil
+}
+
+// release drops the lock so other processes can take it.
+func (l *fileLock) release() error {
+	closeErr := l.f.Close()
+	if removeErr := os.Remove(l.f.Name()); removeErr != nil {
+		return removeErr
+	}
+	return closeErr
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kevin7254/task/model"
+)
+
+const (
+	writerFileEnv  = "TASK_STORE_TEST_WRITER_FILE"
+	writerCountEnv = "TASK_STORE_TEST_WRITER_COUNT"
+)
+
+// TestHelperWriter is not a real test. It is executed in a child process by
+// TestJsonStore_ParallelWriters to add tasks from a separate process.
+func TestHelperWriter(t *testing.T) {
+	filename := os.Getenv(writerFileEnv)
+	if filename == "" {
+		t.Skip("only runs as a child process")
+	}
+	count, _ := strconv.Atoi(os.Getenv(writerCountEnv))
+
+	s, err := NewJsonStore(filename, WithLockTimeout(time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	for i := 0; i < count; i++ {
+		if err := s.AddTask(&model.Task{Title: fmt.Sprintf("pid %d task %d", os.Getpid(), i)}); err != nil {
+			t.Fatalf("Failed to add task: %v", err)
+		}
+	}
+}
+
+func TestJsonStore_ParallelWriters(t *testing.T) {
+	if testing.Short() {
+		t.Skip("spawns child processes")
+	}
+
+	const writers, tasksPerWriter = 4, 15
+	filename := filepath.Join(t.TempDir(), "tasks.json")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			child := exec.Command(os.Args[0], "-test.run=^TestHelperWriter$")
+			child.Env = append(os.Environ(),
+				writerFileEnv+"="+filename,
+				writerCountEnv+"="+strconv.Itoa(tasksPerWriter),
+			)
+			if out, err := child.CombinedOutput(); err != nil {
+				errs <- fmt.Errorf("writer failed: %v\n%s", err, out)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	s, err := NewJsonStore(filename)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	tasks := s.ListAllTasks()
+	if len(tasks) != writers*tasksPerWriter {
+		t.Fatalf("Expected %d tasks, found %d", writers*tasksPerWriter, len(tasks))
+	}
+	for id := 1; id <= writers*tasksPerWriter; id++ {
+		if s.GetTaskByID(id) == nil {
+			t.Errorf("Expected task with ID %d to exist", id)
+		}
+	}
+}
+
+func TestJsonStore_LockTimeout(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "tasks.json")
+	s, err := NewJsonStore(filename, WithLockTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	lock, err := acquireFileLock(filename+".lock", time.Second)
+	if err != nil {
+		t.Fatalf("Failed to take lock: %v", err)
+	}
+
+	addErr := s.AddTask(&model.Task{Title: "Blocked"})
+	if !errors.Is(addErr, ErrLocked) {
+		t.Errorf("Expected ErrLocked while another holder has the lock, got %v", addErr)
+	}
+
+	if err := lock.release(); err != nil {
+		t.Fatalf("Failed to release lock: %v", err)
+	}
+	if err := s.AddTask(&model.Task{Title: "Unblocked"}); err != nil {
+		t.Errorf("Expected add to succeed after lock release, got %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+//go:build unix
+
+package store
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// tryLockFile attempts to take an exclusive flock on filename without blocking.
+func tryLockFile(filename string) (*fileLock, bool, error) {
+	f, osErr := os.OpenFile(filename, os.O_CREATE|os.O_RDWR, 0644)
+	if osErr != nil {
+		return nil, false, osErr
+	}
+
+	if flockErr := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); flockErr != nil {
+		_ = f.Close()
+		if errors.Is(flockErr, syscall.EWOULDBLOCK) {
+			return nil, false, nil
+		}
+		return nil, false, flockErr
+	}
+	return &fileLock{f: f}, true, nil
+}
+
+// release drops the lock so other processes can take it.
+func (l *fileLock) release() error {
+	unlockErr := syscall.Flock(int(l.f.Fd()), syscall.LOCK_UN)
+	closeErr := l.f.Close()
+	if unlockErr != nil {
+		return unlockErr
+	}
+	return closeErr
+}
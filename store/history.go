@@ -0,0 +1,404 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kevin7254/task/model"
+)
+
+// maxHistoryOperations is the number of operations kept in the history log.
+// Older operations are dropped and can no longer be undone.
+const maxHistoryOperations = 100
+
+// ErrNothingToUndo and ErrNothingToRedo are returned when the history has no
+// operation left to undo or redo.
+var (
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrNothingToRedo = errors.New("nothing to redo")
+)
+
+// Change records one task before and after an operation. Before is nil for
+// an added task and After is nil for a removed one.
+type Change struct {
+	ID     int         `json:"id"`
+	Before *model.Task `json:"before,omitempty"`
+	After  *model.Task `json:"after,omitempty"`
+}
+
+// Operation is a reversible group of changes made by a single command.
+type Operation struct {
+	ID      int       `json:"id"`
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	Changes []Change  `json:"changes"`
+	Undone  bool      `json:"undone,omitempty"`
+}
+
+// historyFile is the on-disk format of the history log. Undone operations
+// always form a suffix of Operations and are discarded by the next change.
+type historyFile struct {
+	NextID     int         `json:"next_id"`
+	Operations []Operation `json:"operations"`
+}
+
+// History is the persistent operation log kept next to a task store.
+type History struct {
+	filename    string
+	lockFile    string
+	lockTimeout time.Duration
+}
+
+// HistoryPath returns the history log that belongs to the given store file.
+func HistoryPath(storeFilename string) string {
+	return storeFilename + ".history"
+}
+
+// NewHistory returns the history log persisted to filename.
+func NewHistory(filename string, opts ...Option) *History {
+	options := newOptions(opts)
+	return &History{
+		filename:    filename,
+		lockFile:    filename + ".lock",
+		lockTimeout: options.lockTimeout,
+	}
+}
+
+// Operations returns the recorded operations, oldest first.
+func (h *History) Operations() ([]Operation, error) {
+	file, loadErr := h.load()
+	if loadErr != nil {
+		return nil, loadErr
+	}
+	return file.Operations, nil
+}
+
+// update runs fn against the latest log while holding the history lock and
+// saves the result. The log is saved even if fn fails part way, so changes
+// that were already applied to the store stay recorded.
+func (h *History) update(fn func(file *historyFile) error) error {
+	lock, lockErr := acquireFileLock(h.lockFile, h.lockTimeout)
+	if lockErr != nil {
+		return lockErr
+	}
+	defer lock.release()
+
+	file, loadErr := h.load()
+	if loadErr != nil {
+		return loadErr
+	}
+
+	fnErr := fn(file)
+	if saveErr := h.save(file); saveErr != nil {
+		return errors.Join(fnErr, saveErr)
+	}
+	return fnErr
+}
+
+func (h *History) load() (*historyFile, error) {
+	file := &historyFile{NextID: 1}
+	data, osErr := os.ReadFile(h.filename)
+	if errors.Is(osErr, os.ErrNotExist) {
+		return file, nil
+	}
+	if osErr != nil {
+		return nil, fmt.Errorf("failed to read history: %w", osErr)
+	}
+	if jsonErr := json.Unmarshal(data, file); jsonErr != nil {
+		return nil, fmt.Errorf("failed to unmarshal history: %w", jsonErr)
+	}
+	return file, nil
+}
+
+func (h *History) save(file *historyFile) error {
+	if excess := len(file.Operations) - maxHistoryOperations; excess > 0 {
+		file.Operations = file.Operations[excess:]
+	}
+	data, marshErr := json.MarshalIndent(file, "", "  ")
+	if marshErr != nil {
+		return fmt.Errorf("failed to marshal history: %w", marshErr)
+	}
+	if osErr := writeFileAtomic(h.filename, data, 0644); osErr != nil {
+		return fmt.Errorf("failed to write history: %w", osErr)
+	}
+	return nil
+}
+
+// Recorder is a TaskRepository that records every change made through it in
+// a History, so that it can be undone and redone later. All changes between
+// two calls to Begin form a single operation.
+type Recorder struct {
+	repo    TaskRepository
+	history *History
+	command string
+	current int
+}
+
+// NewRecorder wraps repo so that its changes are recorded in history.
+func NewRecorder(repo TaskRepository, history *History) *Recorder {
+	return &Recorder{repo: repo, history: history}
+}
+
+// History returns the log the recorder writes to.
+func (r *Recorder) History() *History {
+	return r.history
+}
+
+// Begin starts a new operation described by command. The operation is only
+// recorded once it changes a task.
+func (r *Recorder) Begin(command string) {
+	r.command = command
+	r.current = 0
+}
+
+// record adds a change to the current operation, starting a new operation
+// and discarding anything that could be redone if needed. A task changed
+// more than once in an operation keeps its first Before and last After.
+func (r *Recorder) record(id int, before, after *model.Task) error {
+	recordErr := r.history.update(func(file *historyFile) error {
+		last := len(file.Operations) - 1
+		if r.current == 0 || last < 0 || file.Operations[last].ID != r.current {
+			file.Operations = file.Operations[:doneCount(file.Operations)]
+			file.Operations = append(file.Operations, Operation{
+				ID:      file.NextID,
+				Time:    time.Now(),
+				Command: r.command,
+			})
+			r.current = file.NextID
+			file.NextID++
+			last = len(file.Operations) - 1
+		}
+
+		op := &file.Operations[last]
+		for i := range op.Changes {
+			if op.Changes[i].ID == id {
+				op.Changes[i].After = after
+				if op.Changes[i].Before == nil && after == nil {
+					op.Changes = append(op.Changes[:i], op.Changes[i+1:]...)
+				}
+				return nil
+			}
+		}
+		op.Changes = append(op.Changes, Change{ID: id, Before: before, After: after})
+		return nil
+	})
+	if recordErr != nil {
+		return fmt.Errorf("task %d was saved but not recorded in history: %w", id, recordErr)
+	}
+	return nil
+}
+
+// doneCount returns the number of operations that have not been undone.
+func doneCount(ops []Operation) int {
+	for i, op := range ops {
+		if op.Undone {
+			return i
+		}
+	}
+	return len(ops)
+}
+
+// AddTask adds the task and records the addition.
+func (r *Recorder) AddTask(t *model.Task) error {
+	if err := r.repo.AddTask(t); err != nil {
+		return err
+	}
+	return r.record(t.ID, nil, t.Clone())
+}
+
+// RestoreTask restores the task and records it as an addition.
+func (r *Recorder) RestoreTask(t *model.Task) error {
+	if err := r.repo.RestoreTask(t); err != nil {
+		return err
+	}
+	return r.record(t.ID, nil, t.Clone())
+}
+
+// ListAllTasks returns all tasks in the wrapped store.
+func (r *Recorder) ListAllTasks() []*model.Task {
+	return r.repo.ListAllTasks()
+}
+
+// ListTasks returns all tasks in the wrapped store, or the error that kept
+// any of them from being read (see TaskLister).
+func (r *Recorder) ListTasks() ([]*model.Task, error) {
+	return ListTasks(r.repo)
+}
+
+// GetTaskByID retrieves a task from the wrapped store.
+func (r *Recorder) GetTaskByID(id int) *model.Task {
+	return r.repo.GetTaskByID(id)
+}
+
+// UpdateTask updates the task and records its previous state.
+func (r *Recorder) UpdateTask(t *model.Task) error {
+	before := r.repo.GetTaskByID(t.ID)
+	if err := r.repo.UpdateTask(t); err != nil {
+		return err
+	}
+	return r.record(t.ID, before, t.Clone())
+}
+
+// DeleteTask removes the task and records its last state.
+func (r *Recorder) DeleteTask(id int) error {
+	before := r.repo.GetTaskByID(id)
+	if err := r.repo.DeleteTask(id); err != nil {
+		return err
+	}
+	return r.record(id, before, nil)
+}
+
+// Unrecorded returns the wrapped store. Changes made through it are not
+// recorded, so earlier operations on the tasks it changes can no longer be
+// undone.
+func (r *Recorder) Unrecorded() TaskRepository {
+	return r.repo
+}
+
+// MigrationPlan forwards to the wrapped store if it can be migrated.
+func (r *Recorder) MigrationPlan() *MigrationPlan {
+	if migrator, ok := r.repo.(Migrator); ok {
+		return migrator.MigrationPlan()
+	}
+	return nil
+}
+
+// Migrate forwards to the wrapped store if it can be migrated.
+func (r *Recorder) Migrate() (*MigrationPlan, error) {
+	if migrator, ok := r.repo.(Migrator); ok {
+		return migrator.Migrate()
+	}
+	return nil, nil
+}
+
+// Upgraded forwards to the wrapped store if it upgrades itself when opened.
+func (r *Recorder) Upgraded() *MigrationPlan {
+	if upgrader, ok := r.repo.(Upgrader); ok {
+		return upgrader.Upgraded()
+	}
+	return nil
+}
+
+// Undo reverts the last n operations that have not been undone yet, newest
+// first, and returns them. Undo stops at the first operation that cannot be
+// reverted because its tasks were changed outside of the history, and leaves
+// that operation's tasks as they are.
+func (r *Recorder) Undo(n int) ([]Operation, error) {
+	var undone []Operation
+	err := r.history.update(func(file *historyFile) error {
+		for done := doneCount(file.Operations); len(undone) < n; done-- {
+			if done == 0 {
+				if len(undone) == 0 {
+					return ErrNothingToUndo
+				}
+				return nil
+			}
+			op := &file.Operations[done-1]
+			if err := r.revert(op); err != nil {
+				return err
+			}
+			op.Undone = true
+			undone = append(undone, *op)
+		}
+		return nil
+	})
+	r.current = 0
+	return undone, err
+}
+
+// Redo reapplies the next n undone operations, oldest first, and returns them.
+func (r *Recorder) Redo(n int) ([]Operation, error) {
+	var redone []Operation
+	err := r.history.update(func(file *historyFile) error {
+		for next := doneCount(file.Operations); len(redone) < n; next++ {
+			if next == len(file.Operations) {
+				if len(redone) == 0 {
+					return ErrNothingToRedo
+				}
+				return nil
+			}
+			op := &file.Operations[next]
+			if err := r.reapply(op); err != nil {
+				return err
+			}
+			op.Undone = false
+			redone = append(redone, *op)
+		}
+		return nil
+	})
+	r.current = 0
+	return redone, err
+}
+
+// revert puts every task changed by op back into its Before state.
+func (r *Recorder) revert(op *Operation) error {
+	moves := make([]taskMove, len(op.Changes))
+	for i, change := range op.Changes {
+		moves[len(moves)-1-i] = taskMove{id: change.ID, from: change.After, to: change.Before}
+	}
+	return r.move(op, moves)
+}
+
+// reapply puts every task changed by op into its After state.
+func (r *Recorder) reapply(op *Operation) error {
+	moves := make([]taskMove, len(op.Changes))
+	for i, change := range op.Changes {
+		moves[i] = taskMove{id: change.ID, from: change.Before, to: change.After}
+	}
+	return r.move(op, moves)
+}
+
+// taskMove takes task id from state from to state to. A nil state means the
+// task does not exist.
+type taskMove struct {
+	id       int
+	from, to *model.Task
+}
+
+// move applies the moves of op all or nothing. Every task is checked to still
+// be in its from state before any is changed, and if a move fails, the moves
+// already made are taken back.
+func (r *Recorder) move(op *Operation, moves []taskMove) error {
+	for _, m := range moves {
+		if !sameTask(r.repo.GetTaskByID(m.id), m.from) {
+			return fmt.Errorf("task %d has changed since operation %d (%s)", m.id, op.ID, op.Command)
+		}
+	}
+	for i, m := range moves {
+		if err := r.set(m.id, m.from, m.to); err != nil {
+			for j := i - 1; j >= 0; j-- {
+				if backErr := r.set(moves[j].id, moves[j].to, moves[j].from); backErr != nil {
+					err = errors.Join(err, backErr)
+				}
+			}
+			return fmt.Errorf("failed to restore task %d: %w", m.id, err)
+		}
+	}
+	return nil
+}
+
+// set stores state to for task id, which is currently in state from.
+func (r *Recorder) set(id int, from, to *model.Task) error {
+	switch {
+	case to == nil:
+		return r.repo.DeleteTask(id)
+	case from == nil:
+		return r.repo.RestoreTask(to.Clone())
+	default:
+		return r.repo.UpdateTask(to.Clone())
+	}
+}
+
+// sameTask reports whether two task states are identical once stored.
+func sameTask(a, b *model.Task) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	aData, aErr := json.Marshal(a)
+	bData, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && bytes.Equal(aData, bData)
+}
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/kevin7254/task/model"
+)
+
+func newTestRecorder(t *testing.T) (*Recorder, *JsonStore) {
+	t.Helper()
+	dir := t.TempDir()
+	s, err := NewJsonStore(filepath.Join(dir, "tasks.json"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	return NewRecorder(s, NewHistory(filepath.Join(dir, "tasks.json.history"))), s
+}
+
+func TestRecorder_GroupsChangesPerOperation(t *testing.T) {
+	r, s := newTestRecorder(t)
+
+	r.Begin("add")
+	task := &model.Task{Title: "Draft"}
+	if err := r.AddTask(task); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+	task.Title = "Final"
+	if err := r.UpdateTask(task); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	other := &model.Task{Title: "Other"}
+	if err := r.AddTask(other); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+
+	ops, err := r.History().Operations()
+	if err != nil {
+		t.Fatalf("Operations failed: %v", err)
+	}
+	if len(ops) != 1 || len(ops[0].Changes) != 2 {
+		t.Fatalf("Expected one operation with two changes, got %+v", ops)
+	}
+	if ops[0].Changes[0].Before != nil || ops[0].Changes[0].After.Title != "Final" {
+		t.Errorf("Expected repeated changes to a task to be merged, got %+v", ops[0].Changes[0])
+	}
+
+	if _, err := r.Undo(1); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if len(s.ListAllTasks()) != 0 {
+		t.Errorf("Expected undo to remove both added tasks, got %d", len(s.ListAllTasks()))
+	}
+	if _, err := r.Undo(1); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("Expected ErrNothingToUndo, got %v", err)
+	}
+}
+
+func TestRecorder_RefusesToUndoOutsideChanges(t *testing.T) {
+	r, s := newTestRecorder(t)
+
+	r.Begin("add")
+	task := &model.Task{Title: "Recorded"}
+	if err := r.AddTask(task); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+
+	// Change the task directly, bypassing the history.
+	task.Title = "Changed elsewhere"
+	if err := s.UpdateTask(task); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+
+	if _, err := r.Undo(1); err == nil {
+		t.Fatal("Expected undo to fail after an unrecorded change")
+	}
+	if s.GetTaskByID(task.ID) == nil {
+		t.Error("Expected the task to be kept when undo fails")
+	}
+	ops, _ := r.History().Operations()
+	if len(ops) != 1 || ops[0].Undone {
+		t.Errorf("Expected the operation to stay undoable, got %+v", ops)
+	}
+}
+
+func TestRecorder_UndoesOperationsWhole(t *testing.T) {
+	r, s := newTestRecorder(t)
+	for _, title := range []string{"First", "Second", "Third"} {
+		if err := s.AddTask(&model.Task{Title: title}); err != nil {
+			t.Fatalf("AddTask failed: %v", err)
+		}
+	}
+
+	// A bulk command completes two tasks and removes a third.
+	r.Begin("do 1 2")
+	for _, id := range []int{1, 2} {
+		task := s.GetTaskByID(id)
+		task.Status = model.StatusDone
+		if err := r.UpdateTask(task); err != nil {
+			t.Fatalf("UpdateTask failed: %v", err)
+		}
+	}
+	if err := r.DeleteTask(3); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+
+	// Task 1 is reverted last, so the other changes would be undone before
+	// its conflict was found.
+	first := s.GetTaskByID(1)
+	first.Title = "Changed elsewhere"
+	if err := s.UpdateTask(first); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	if _, err := r.Undo(1); err == nil {
+		t.Fatal("Expected undo to fail after an unrecorded change")
+	}
+	if s.GetTaskByID(2).Status != model.StatusDone || s.GetTaskByID(3) != nil {
+		t.Errorf("Expected a failed undo to change nothing, got %v", s.ListAllTasks())
+	}
+
+	first.Title = "First"
+	if err := s.UpdateTask(first); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	if _, err := r.Undo(1); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if s.GetTaskByID(1).Status == model.StatusDone || s.GetTaskByID(2).Status == model.StatusDone || s.GetTaskByID(3) == nil {
+		t.Errorf("Expected the whole operation to be undone, got %v", s.ListAllTasks())
+	}
+}
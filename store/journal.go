@@ -0,0 +1,117 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/kevin7254/task/model"
+)
+
+// journalOp identifies the kind of mutation recorded in a journal entry.
+type journalOp string
+
+const (
+	journalAdd    journalOp = "add"
+	journalUpdate journalOp = "update"
+	journalDelete journalOp = "delete"
+)
+
+// journalEntry is a single mutation recorded in the write-ahead journal.
+type journalEntry struct {
+	Op   journalOp   `json:"op"`
+	ID   int         `json:"id"`
+	Task *model.Task `json:"task,omitempty"`
+}
+
+// journal is an append-only log of mutations that have not yet been
+// persisted to the main store file. Each entry is one JSON document per line.
+type journal struct {
+	filename string
+}
+
+// newJournal returns the journal that belongs to the given store file.
+func newJournal(storeFilename string) *journal {
+	return &journal{filename: storeFilename + ".journal"}
+}
+
+// append durably records an entry before the store file is rewritten and
+// returns the size of the journal before it, which truncate can go back to.
+// An entry that could not be written completely is removed again.
+func (j *journal) append(entry journalEntry) (int64, error) {
+	line, marshErr := json.Marshal(entry)
+	if marshErr != nil {
+		return 0, fmt.Errorf("failed to marshal journal entry: %w", marshErr)
+	}
+
+	f, osErr := os.OpenFile(j.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if osErr != nil {
+		return 0, fmt.Errorf("failed to open journal: %w", osErr)
+	}
+	defer f.Close()
+
+	info, statErr := f.Stat()
+	if statErr != nil {
+		return 0, fmt.Errorf("failed to read journal: %w", statErr)
+	}
+	offset := info.Size()
+	if _, writeErr := f.Write(append(line, '\n')); writeErr != nil {
+		_ = f.Truncate(offset)
+		return offset, fmt.Errorf("failed to write journal: %w", writeErr)
+	}
+	if syncErr := f.Sync(); syncErr != nil {
+		_ = f.Truncate(offset)
+		return offset, fmt.Errorf("failed to sync journal: %w", syncErr)
+	}
+	return offset, nil
+}
+
+// truncate discards the entries appended after the journal was offset bytes
+// long.
+func (j *journal) truncate(offset int64) error {
+	if osErr := os.Truncate(j.filename, offset); osErr != nil && !errors.Is(osErr, os.ErrNotExist) {
+		return fmt.Errorf("failed to truncate journal: %w", osErr)
+	}
+	return nil
+}
+
+// entries returns all complete entries in the journal. A trailing entry that
+// was only partially written before a crash is ignored.
+func (j *journal) entries() ([]journalEntry, error) {
+	data, osErr := os.ReadFile(j.filename)
+	if osErr != nil {
+		if errors.Is(osErr, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read journal: %w", osErr)
+	}
+
+	var entries []journalEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var entry journalEntry
+		if jsonErr := json.Unmarshal(line, &entry); jsonErr != nil {
+			// A torn write can only affect the last line, and anything after
+			// it was never acknowledged to the caller.
+			break
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// reset discards all entries once they are reflected in the store file.
+func (j *journal) reset() error {
+	if osErr := os.Remove(j.filename); osErr != nil && !errors.Is(osErr, os.ErrNotExist) {
+		return fmt.Errorf("failed to reset journal: %w", osErr)
+	}
+	return nil
+}
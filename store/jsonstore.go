@@ -2,144 +2,392 @@ package store
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/kevin7254/task/model"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
+	"time"
 )
 
 // JsonStore implements the model.TaskRepository for persisting tasks to a JSON file.
+// Every mutation is first appended to a write-ahead journal and the store file
+// is then replaced atomically, so a crash can never leave a truncated file.
+// Mutations hold an advisory lock on the store across load-modify-save, so
+// concurrent task processes cannot overwrite each other's changes.
+// Files written by older versions are upgraded in memory when loaded; the
+// original is backed up before the upgraded format is first saved.
 type JsonStore struct {
-	filename string
-	tasks    map[int]*model.Task
-	mu       sync.RWMutex
-	nextID   int
+	filename    string
+	lockFile    string
+	lockTimeout time.Duration
+	journal     *journal
+	tasks       map[int]*model.Task
+	mu          sync.RWMutex
+	nextID      int
+	diskVersion int
+	pending     []Migration
 }
 
 // NewJsonStore creates a new JsonStore instance that persists tasks to the specified file.
-// It creates the directory if it doesn't exist and loads any existing tasks.
-func NewJsonStore(filename string) (*JsonStore, error) {
+// It creates the directory if it doesn't exist, loads any existing tasks and
+// replays mutations left in the journal by an interrupted save.
+func NewJsonStore(filename string, opts ...Option) (*JsonStore, error) {
 	if osErr := os.MkdirAll(filepath.Dir(filename), 0755); osErr != nil {
 		return nil, fmt.Errorf("failed to create directory: %w", osErr)
 	}
 
+	options := newOptions(opts)
 	jsonStore := &JsonStore{
-		filename: filename,
-		tasks:    make(map[int]*model.Task),
-		nextID:   1,
+		filename:    filename,
+		lockFile:    filename + ".lock",
+		lockTimeout: options.lockTimeout,
+		journal:     newJournal(filename),
+		tasks:       make(map[int]*model.Task),
+		nextID:      1,
 	}
 
-	if jsonErr := jsonStore.load(); jsonErr != nil {
-		if !os.IsNotExist(jsonErr) {
-			return nil, fmt.Errorf("failed to load tasks: %w", jsonErr)
+	lock, lockErr := acquireFileLock(jsonStore.lockFile, jsonStore.lockTimeout)
+	if lockErr != nil {
+		return nil, lockErr
+	}
+	defer lock.release()
+
+	jsonStore.mu.Lock()
+	defer jsonStore.mu.Unlock()
+
+	if refreshErr := jsonStore.refresh(); refreshErr != nil {
+		return nil, refreshErr
+	}
+
+	return jsonStore, nil
+}
+
+// refresh reloads the tasks from disk and recovers any journaled mutations.
+// The caller must hold both the file lock and the write lock.
+func (s *JsonStore) refresh() error {
+	if jsonErr := s.load(); jsonErr != nil {
+		if !errors.Is(jsonErr, os.ErrNotExist) {
+			return fmt.Errorf("failed to load tasks: %w", jsonErr)
 		}
 		// File doesn't exist yet, which is fine for a new store
-	} else {
-		jsonStore.updateNextID()
 	}
 
-	return jsonStore, nil
+	if replayErr := s.replayJournal(); replayErr != nil {
+		return fmt.Errorf("failed to recover tasks from journal: %w", replayErr)
+	}
+	s.updateNextID()
+	return nil
 }
 
-// updateNextID sets the nextID to be one more than the highest ID in the tasks map.
+// mutate runs fn against the latest on-disk state while holding the store
+// lock, then durably commits the journal entry it returns.
+func (s *JsonStore) mutate(fn func() (journalEntry, error)) error {
+	lock, lockErr := acquireFileLock(s.lockFile, s.lockTimeout)
+	if lockErr != nil {
+		return lockErr
+	}
+	defer lock.release()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if refreshErr := s.refresh(); refreshErr != nil {
+		return refreshErr
+	}
+
+	entry, fnErr := fn()
+	if fnErr != nil {
+		return fnErr
+	}
+	return s.commit(entry)
+}
+
+// replayJournal applies entries left behind by a save that did not complete,
+// persists the recovered state and clears the journal.
+func (s *JsonStore) replayJournal() error {
+	entries, journalErr := s.journal.entries()
+	if journalErr != nil {
+		return journalErr
+	}
+	if len(entries) == 0 {
+		return s.journal.reset()
+	}
+
+	for _, entry := range entries {
+		s.apply(entry)
+	}
+
+	if saveErr := s.save(); saveErr != nil {
+		return saveErr
+	}
+	return s.journal.reset()
+}
+
+// apply performs the mutation described by entry on the in-memory tasks.
+// The caller must hold the write lock.
+func (s *JsonStore) apply(entry journalEntry) {
+	switch entry.Op {
+	case journalAdd, journalUpdate:
+		if entry.Task != nil {
+			s.tasks[entry.Task.ID] = entry.Task
+		}
+	case journalDelete:
+		delete(s.tasks, entry.ID)
+	}
+}
+
+// commit makes a mutation durable: it is journaled first, then the store file
+// is rewritten and finally the journal is cleared. If the mutation cannot be
+// made durable, its journal entry is dropped and the tasks are reloaded from
+// disk, so that neither this process nor the next one to open the store sees
+// a change that was reported as failed.
+func (s *JsonStore) commit(entry journalEntry) error {
+	offset, journalErr := s.journal.append(entry)
+	if journalErr != nil {
+		return s.rollback(journalErr)
+	}
+	if saveErr := s.save(); saveErr != nil {
+		if truncateErr := s.journal.truncate(offset); truncateErr != nil {
+			// The entry stays and is replayed by the next refresh, so the
+			// in-memory tasks already show what the store will hold.
+			return errors.Join(saveErr, truncateErr)
+		}
+		return s.rollback(saveErr)
+	}
+	return s.journal.reset()
+}
+
+// rollback discards the in-memory effect of a mutation that failed with
+// cause by reloading the tasks from disk.
+// The caller must hold both the file lock and the write lock.
+func (s *JsonStore) rollback(cause error) error {
+	s.tasks = make(map[int]*model.Task)
+	s.nextID = 1
+	s.pending = nil
+	if refreshErr := s.refresh(); refreshErr != nil {
+		return errors.Join(cause, refreshErr)
+	}
+	return cause
+}
+
+// updateNextID raises nextID above the highest ID in the tasks map. It never
+// lowers it, so IDs of removed tasks are not handed out again.
 func (s *JsonStore) updateNextID() {
-	highestID := 0
 	for id := range s.tasks {
-		if id > highestID {
-			highestID = id
+		if id >= s.nextID {
+			s.nextID = id + 1
 		}
 	}
-	s.nextID = highestID + 1
 }
 
-// save persists the tasks to the store file.
+// save persists the tasks to the store file in the current schema version,
+// backing up the original first if it was written in an older version.
+// The caller must hold the write lock.
 func (s *JsonStore) save() error {
-	s.mu.RLock()
-	bytes, marshErr := json.MarshalIndent(s.tasks, "", "  ")
-	s.mu.RUnlock()
+	if len(s.pending) > 0 {
+		if backupErr := s.backupOriginal(); backupErr != nil {
+			return backupErr
+		}
+	}
+
+	file := taskFile{Version: CurrentSchemaVersion, NextID: s.nextID, Tasks: make([]*model.Task, 0, len(s.tasks))}
+	for _, t := range s.tasks {
+		file.Tasks = append(file.Tasks, t)
+	}
+	sort.Slice(file.Tasks, func(i, j int) bool {
+		return file.Tasks[i].ID < file.Tasks[j].ID
+	})
 
+	bytes, marshErr := json.MarshalIndent(file, "", "  ")
 	if marshErr != nil {
 		return fmt.Errorf("failed to marshal tasks: %w", marshErr)
 	}
 
-	if osErr := os.WriteFile(s.filename, bytes, 0644); osErr != nil {
+	if osErr := writeFileAtomic(s.filename, bytes, 0644); osErr != nil {
 		return fmt.Errorf("failed to write tasks to file: %w", osErr)
 	}
 
+	s.diskVersion = CurrentSchemaVersion
+	s.pending = nil
+	return nil
+}
+
+// backupOriginal copies the store file, as written by an older version, next
+// to it before it is overwritten in the current format.
+func (s *JsonStore) backupOriginal() error {
+	original, osErr := os.ReadFile(s.filename)
+	if osErr != nil {
+		if errors.Is(osErr, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read tasks for backup: %w", osErr)
+	}
+	if writeErr := writeFileAtomic(backupPath(s.filename, s.diskVersion), original, 0644); writeErr != nil {
+		return fmt.Errorf("failed to back up tasks before migration: %w", writeErr)
+	}
 	return nil
 }
 
-// load reads tasks from the store file.
+// load reads tasks from the store file, replacing the in-memory state.
+// The caller must hold the write lock.
 func (s *JsonStore) load() error {
 	bytes, osErr := os.ReadFile(s.filename)
 	if osErr != nil {
 		return osErr
 	}
 
+	file, applied, decodeErr := decodeTaskFile(bytes)
+	if decodeErr != nil {
+		return fmt.Errorf("failed to unmarshal tasks: %w", decodeErr)
+	}
+
+	tasks := make(map[int]*model.Task, len(file.Tasks))
+	for _, t := range file.Tasks {
+		tasks[t.ID] = t
+	}
+	s.tasks = tasks
+	s.nextID = max(file.NextID, 1)
+	s.diskVersion = file.Version
+	s.pending = applied
+
+	return nil
+}
+
+// MigrationPlan returns the upgrade that the next save will persist, or nil
+// if the store file is already in the current format.
+func (s *JsonStore) MigrationPlan() *MigrationPlan {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.migrationPlan()
+}
+
+// migrationPlan builds the plan for the pending migrations.
+// The caller must hold at least the read lock.
+func (s *JsonStore) migrationPlan() *MigrationPlan {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	return &MigrationPlan{
+		FromVersion: s.diskVersion,
+		ToVersion:   CurrentSchemaVersion,
+		Steps:       append([]Migration(nil), s.pending...),
+		TaskCount:   len(s.tasks),
+		BackupPath:  backupPath(s.filename, s.diskVersion),
+	}
+}
+
+// Migrate backs up the store file and rewrites it in the current format.
+// It returns the applied plan, or nil if the file was already up to date.
+func (s *JsonStore) Migrate() (*MigrationPlan, error) {
+	lock, lockErr := acquireFileLock(s.lockFile, s.lockTimeout)
+	if lockErr != nil {
+		return nil, lockErr
+	}
+	defer lock.release()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if unMarshalErr := json.Unmarshal(bytes, &s.tasks); unMarshalErr != nil {
-		return fmt.Errorf("failed to unmarshal tasks: %w", unMarshalErr)
+	if refreshErr := s.refresh(); refreshErr != nil {
+		return nil, refreshErr
 	}
 
-	return nil
+	plan := s.migrationPlan()
+	if plan == nil {
+		return nil, nil
+	}
+	if saveErr := s.save(); saveErr != nil {
+		return nil, saveErr
+	}
+	return plan, nil
 }
 
-// ListAllTasks returns all tasks in the store.
+// ListAllTasks returns copies of all tasks in the store.
 func (s *JsonStore) ListAllTasks() []*model.Task {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	tasks := make([]*model.Task, 0, len(s.tasks))
 	for _, t := range s.tasks {
-		tasks = append(tasks, t)
+		tasks = append(tasks, t.Clone())
 	}
 	return tasks
 }
 
-// GetTaskByID retrieves a task by its ID.
+// GetTaskByID retrieves a copy of the task with the given ID.
 // Returns nil if no task with the given ID exists.
 func (s *JsonStore) GetTaskByID(id int) *model.Task {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	return s.tasks[id]
+	t, exists := s.tasks[id]
+	if !exists {
+		return nil
+	}
+	return t.Clone()
 }
 
-// AddTask adds a task to the store and assigns it a unique ID.
-// Returns an error if the operation fails.
+// AddTask adds a task to the store and assigns it a unique ID, and a UUID if
+// it has none. Returns an error if the operation fails.
 func (s *JsonStore) AddTask(t *model.Task) error {
-	s.mu.Lock()
-	t.ID = s.nextID
-	s.nextID++
-	s.tasks[t.ID] = t
-	s.mu.Unlock()
+	return s.mutate(func() (journalEntry, error) {
+		t.ID = s.nextID
+		s.nextID++
+		if t.UUID == "" {
+			t.UUID = model.NewUUID()
+		}
+		stored := t.Clone()
+		s.tasks[t.ID] = stored
+		return journalEntry{Op: journalAdd, ID: t.ID, Task: stored}, nil
+	})
+}
 
-	return s.save()
+// RestoreTask adds a previously removed task back under its original ID.
+// Returns an error if a task with that ID already exists.
+func (s *JsonStore) RestoreTask(t *model.Task) error {
+	return s.mutate(func() (journalEntry, error) {
+		if _, exists := s.tasks[t.ID]; exists {
+			return journalEntry{}, fmt.Errorf("task with ID %d already exists", t.ID)
+		}
+
+		stored := t.Clone()
+		s.tasks[t.ID] = stored
+		return journalEntry{Op: journalAdd, ID: t.ID, Task: stored}, nil
+	})
 }
 
 // UpdateTask updates an existing task.
-// Returns an error if the task doesn't exist or the operation fails.
+// Returns an error if the task doesn't exist, its UUID would change or the
+// operation fails.
 func (s *JsonStore) UpdateTask(t *model.Task) error {
-	s.mu.Lock()
-
-	if _, exists := s.tasks[t.ID]; !exists {
-		return fmt.Errorf("task with ID %d does not exist", t.ID)
-	}
+	return s.mutate(func() (journalEntry, error) {
+		existing, exists := s.tasks[t.ID]
+		if !exists {
+			return journalEntry{}, fmt.Errorf("task with ID %d does not exist", t.ID)
+		}
+		if existing.UUID != t.UUID {
+			return journalEntry{}, fmt.Errorf("%w (task %d)", ErrUUIDChanged, t.ID)
+		}
 
-	s.tasks[t.ID] = t
-	s.mu.Unlock()
-	return s.save()
+		stored := t.Clone()
+		s.tasks[t.ID] = stored
+		return journalEntry{Op: journalUpdate, ID: t.ID, Task: stored}, nil
+	})
 }
 
 // DeleteTask removes a task from the store.
-// Returns an error if the operation fails.
+// Returns an error if the task doesn't exist or the operation fails.
 func (s *JsonStore) DeleteTask(id int) error {
-	s.mu.Lock()
-	delete(s.tasks, id)
-	s.mu.Unlock()
+	return s.mutate(func() (journalEntry, error) {
+		if _, exists := s.tasks[id]; !exists {
+			return journalEntry{}, fmt.Errorf("task with ID %d does not exist", id)
+		}
 
-	return s.save()
+		delete(s.tasks, id)
+		return journalEntry{Op: journalDelete, ID: id}, nil
+	})
 }
@@ -0,0 +1,317 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kevin7254/task/model"
+)
+
+func TestJsonStore_ReplaysJournal(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "tasks.json")
+
+	s, err := NewJsonStore(filename)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	if err := s.AddTask(&model.Task{Title: "Saved"}); err != nil {
+		t.Fatalf("Failed to add task: %v", err)
+	}
+
+	// Simulate a crash after the journal was written but before the store
+	// file was replaced.
+	j := newJournal(filename)
+	if _, err := j.append(journalEntry{Op: journalAdd, ID: 2, Task: &model.Task{ID: 2, Title: "Journaled"}}); err != nil {
+		t.Fatalf("Failed to append journal entry: %v", err)
+	}
+	if _, err := j.append(journalEntry{Op: journalDelete, ID: 1}); err != nil {
+		t.Fatalf("Failed to append journal entry: %v", err)
+	}
+	f, err := os.OpenFile(j.filename, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open journal: %v", err)
+	}
+	if _, err := f.WriteString(`{"op":"add","id":3,"task":{"id":3,"ti`); err != nil {
+		t.Fatalf("Failed to write torn entry: %v", err)
+	}
+	_ = f.Close()
+
+	recovered, err := NewJsonStore(filename)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	if recovered.GetTaskByID(1) != nil {
+		t.Errorf("Expected task 1 to be deleted by journal replay")
+	}
+	if got := recovered.GetTaskByID(2); got == nil || got.Title != "Journaled" {
+		t.Errorf("Expected journaled task 2 to be recovered, got %v", got)
+	}
+	if recovered.GetTaskByID(3) != nil {
+		t.Errorf("Expected torn journal entry to be ignored")
+	}
+	if _, err := os.Stat(j.filename); !os.IsNotExist(err) {
+		t.Errorf("Expected journal to be cleared after replay, stat error: %v", err)
+	}
+
+	reopened, err := NewJsonStore(filename)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	if len(reopened.ListAllTasks()) != 1 {
+		t.Errorf("Expected recovered state to be persisted, got %d tasks", len(reopened.ListAllTasks()))
+	}
+}
+
+func TestJsonStore_FailedSaveIsDiscarded(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "tasks.json")
+	legacy := `{"1": {"id": 1, "title": "First"}, "3": {"id": 3, "title": "Third"}}`
+	if err := os.WriteFile(filename, []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to write legacy file: %v", err)
+	}
+	s, err := NewJsonStore(filename)
+	if err != nil {
+		t.Fatalf("Failed to open legacy store: %v", err)
+	}
+
+	// The first save backs up the legacy file, which fails while a
+	// directory is in the way.
+	backup := s.MigrationPlan().BackupPath
+	if err := os.MkdirAll(filepath.Join(backup, "occupied"), 0755); err != nil {
+		t.Fatalf("Failed to block the backup: %v", err)
+	}
+	if err := s.AddTask(&model.Task{Title: "Lost"}); err == nil {
+		t.Fatal("Expected AddTask to fail when the store cannot be saved")
+	}
+	if got := len(s.ListAllTasks()); got != 2 {
+		t.Errorf("Expected the failed add to be rolled back, got %d tasks", got)
+	}
+	if entries, err := newJournal(filename).entries(); err != nil || len(entries) != 0 {
+		t.Errorf("Expected the failed add to be dropped from the journal, got %v (err %v)", entries, err)
+	}
+
+	if err := os.RemoveAll(backup); err != nil {
+		t.Fatalf("Failed to unblock the backup: %v", err)
+	}
+	reopened, err := NewJsonStore(filename)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	if got := len(reopened.ListAllTasks()); got != 2 {
+		t.Errorf("Expected the failed add not to be replayed, got %d tasks", got)
+	}
+	task := &model.Task{Title: "Added"}
+	if err := s.AddTask(task); err != nil {
+		t.Fatalf("Failed to add task: %v", err)
+	}
+	if task.ID != 4 {
+		t.Errorf("Expected the next ID to be 4, got %d", task.ID)
+	}
+}
+
+func TestJsonStore_SaveLeavesNoTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewJsonStore(filepath.Join(dir, "tasks.json"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := s.AddTask(&model.Task{Title: "Task"}); err != nil {
+			t.Fatalf("Failed to add task: %v", err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read dir: %v", err)
+	}
+	for _, e := range entries {
+		if e.Name() != "tasks.json" && e.Name() != "tasks.json.lock" {
+			t.Errorf("Unexpected file left in store directory: %s", e.Name())
+		}
+	}
+}
+
+func TestJsonStore_MigratesLegacyFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "tasks.json")
+	legacy := `{
+  "1": {"id": 1, "title": "First", "project": "work", "priority": 2},
+  "3": {"id": 3, "title": "Third", "project": "home", "priority": 1}
+}`
+	if err := os.WriteFile(filename, []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to write legacy file: %v", err)
+	}
+
+	s, err := NewJsonStore(filename)
+	if err != nil {
+		t.Fatalf("Failed to open legacy store: %v", err)
+	}
+	if got := s.GetTaskByID(3); got == nil || got.Title != "Third" {
+		t.Fatalf("Expected legacy task 3 to load, got %v", got)
+	}
+
+	plan := s.MigrationPlan()
+	if plan == nil || plan.FromVersion != 1 || plan.ToVersion != CurrentSchemaVersion || plan.TaskCount != 2 {
+		t.Fatalf("Unexpected migration plan: %+v", plan)
+	}
+	if data, _ := os.ReadFile(filename); string(data) != legacy {
+		t.Errorf("Expected the legacy file to be untouched before saving")
+	}
+
+	if err := s.AddTask(&model.Task{Title: "New"}); err != nil {
+		t.Fatalf("Failed to add task: %v", err)
+	}
+	if got := s.ListAllTasks(); len(got) != 3 {
+		t.Errorf("Expected 3 tasks after add, got %d", len(got))
+	}
+	if s.MigrationPlan() != nil {
+		t.Errorf("Expected no pending migrations after saving")
+	}
+
+	backup, err := os.ReadFile(plan.BackupPath)
+	if err != nil {
+		t.Fatalf("Expected a backup of the legacy file: %v", err)
+	}
+	if string(backup) != legacy {
+		t.Errorf("Expected backup to hold the original contents, got %s", backup)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("Failed to read store file: %v", err)
+	}
+	file, applied, err := decodeTaskFile(data)
+	if err != nil || file.Version != CurrentSchemaVersion || len(applied) != 0 {
+		t.Fatalf("Expected store file at version %d, got %+v (err %v)", CurrentSchemaVersion, file, err)
+	}
+}
+
+func TestJsonStore_MigratesTimeSpent(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "tasks.json")
+	v2 := `{"version": 2, "tasks": [
+  {"id": 1, "title": "Done", "created_at": "2025-06-01T09:00:00Z", "completed_at": "2025-06-02T17:00:00Z", "time_spent": 90},
+  {"id": 2, "title": "Open", "created_at": "2025-06-03T09:00:00Z", "completed_at": "0001-01-01T00:00:00Z", "time_spent": 30},
+  {"id": 3, "title": "Untracked", "created_at": "2025-06-03T09:00:00Z", "time_spent": 0}
+]}`
+	if err := os.WriteFile(filename, []byte(v2), 0644); err != nil {
+		t.Fatalf("Failed to write store file: %v", err)
+	}
+
+	s, err := NewJsonStore(filename)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+
+	done := s.GetTaskByID(1)
+	want := []model.TimeEntry{{
+		Start:  time.Date(2025, 6, 2, 15, 30, 0, 0, time.UTC),
+		End:    time.Date(2025, 6, 2, 17, 0, 0, 0, time.UTC),
+		Legacy: true,
+	}}
+	if !reflect.DeepEqual(done.TimeEntries, want) {
+		t.Errorf("Expected a legacy entry ending at completion, got %+v", done.TimeEntries)
+	}
+	if open := s.GetTaskByID(2); len(open.TimeEntries) != 1 || !open.TimeEntries[0].End.Equal(open.CreatedAt) || open.TimeSpent() != 30 {
+		t.Errorf("Expected a 30 minute legacy entry ending at creation, got %+v", open.TimeEntries)
+	}
+	if untracked := s.GetTaskByID(3); untracked.TimeEntries != nil {
+		t.Errorf("Expected no entries for a task without time spent, got %+v", untracked.TimeEntries)
+	}
+}
+
+func TestJsonStore_MigratesStatus(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "tasks.json")
+	v3 := `{"version": 3, "tasks": [
+  {"id": 1, "title": "Done", "created_at": "2025-06-01T09:00:00Z", "completed_at": "2025-06-02T17:00:00Z"},
+  {"id": 2, "title": "Open", "created_at": "2025-06-03T09:00:00Z", "completed_at": "0001-01-01T00:00:00Z"},
+  {"id": 3, "title": "Never completed", "created_at": "2025-06-03T09:00:00Z"}
+]}`
+	if err := os.WriteFile(filename, []byte(v3), 0644); err != nil {
+		t.Fatalf("Failed to write store file: %v", err)
+	}
+
+	s, err := NewJsonStore(filename)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+
+	want := map[int]model.Status{1: model.StatusDone, 2: model.StatusPending, 3: model.StatusPending}
+	for id, status := range want {
+		if got := s.GetTaskByID(id).Status; got != status {
+			t.Errorf("Expected task %d to be %s, got %q", id, status, got)
+		}
+	}
+}
+
+func TestJsonStore_MigratesUUIDs(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "tasks.json")
+	v4 := `{"version": 4, "tasks": [
+  {"id": 1, "title": "Old", "created_at": "2025-06-01T09:00:00Z", "status": "pending"},
+  {"id": 2, "title": "Older", "created_at": "2025-06-01T09:00:00Z", "status": "pending"},
+  {"id": 3, "title": "Imported", "uuid": "0f8fad5b-d9cb-469f-a165-70867728950e", "created_at": "2025-06-01T09:00:00Z", "status": "pending"}
+]}`
+	if err := os.WriteFile(filename, []byte(v4), 0644); err != nil {
+		t.Fatalf("Failed to write store file: %v", err)
+	}
+
+	s, err := NewJsonStore(filename)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+
+	first, second := s.GetTaskByID(1).UUID, s.GetTaskByID(2).UUID
+	if first == "" || second == "" || first == second {
+		t.Errorf("Expected distinct UUIDs to be assigned, got %q and %q", first, second)
+	}
+	if got := s.GetTaskByID(3).UUID; got != "0f8fad5b-d9cb-469f-a165-70867728950e" {
+		t.Errorf("Expected an existing UUID to be kept, got %q", got)
+	}
+}
+
+func TestJsonStore_LegacyUUIDsAreStable(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "tasks.json")
+	legacy := `{"1": {"id": 1, "title": "First", "created_at": "2025-06-01T09:00:00Z"}}`
+	if err := os.WriteFile(filename, []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to write legacy file: %v", err)
+	}
+
+	s, err := NewJsonStore(filename)
+	if err != nil {
+		t.Fatalf("Failed to open legacy store: %v", err)
+	}
+	task := s.GetTaskByID(1)
+	other, err := NewJsonStore(filename)
+	if err != nil {
+		t.Fatalf("Failed to open legacy store again: %v", err)
+	}
+	if got := other.GetTaskByID(1).UUID; got != task.UUID {
+		t.Errorf("Expected every load of the legacy file to assign UUID %s, got %s", task.UUID, got)
+	}
+
+	// The update reloads and migrates the file again before applying.
+	task.Title = "Renamed"
+	if err := s.UpdateTask(task); err != nil {
+		t.Fatalf("Expected updating a task of a legacy file to work, got %v", err)
+	}
+
+	reopened, err := NewJsonStore(filename)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	if got := reopened.GetTaskByID(1); got.Title != "Renamed" || got.UUID != task.UUID {
+		t.Errorf("Expected the renamed task to keep UUID %s, got %+v", task.UUID, got)
+	}
+}
+
+func TestJsonStore_RejectsNewerSchema(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "tasks.json")
+	if err := os.WriteFile(filename, []byte(`{"version": 999, "tasks": []}`), 0644); err != nil {
+		t.Fatalf("Failed to write store file: %v", err)
+	}
+	if _, err := NewJsonStore(filename); !errors.Is(err, ErrNewerSchema) {
+		t.Errorf("Expected ErrNewerSchema, got %v", err)
+	}
+}
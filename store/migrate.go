@@ -0,0 +1,300 @@
+package store
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/kevin7254/task/model"
+)
+
+// CurrentSchemaVersion is the version of the stored task format written by
+// this build of task.
+const CurrentSchemaVersion = 5
+
+// legacySchemaVersion is the unversioned format: a bare JSON object mapping
+// task IDs to tasks.
+const legacySchemaVersion = 1
+
+// ErrNewerSchema is returned when the stored tasks were written by a newer
+// version of task than this one.
+var ErrNewerSchema = errors.New("task store was written by a newer version of task")
+
+// Migration upgrades stored tasks from one schema version to the next.
+type Migration struct {
+	// From is the schema version the migration upgrades; it produces From+1.
+	From int
+	// Description explains what the migration changes.
+	Description string
+	// Apply rewrites the decoded tasks in place. It is nil for migrations
+	// that only change the file layout.
+	Apply func(tasks []map[string]any) error
+}
+
+// migrations is the registry of all schema upgrades, ordered by From.
+var migrations = []Migration{
+	{
+		From:        1,
+		Description: "wrap the bare task map in a versioned envelope",
+	},
+	{
+		From:        2,
+		Description: "move the minutes in time_spent into a legacy time entry",
+		Apply:       migrateTimeSpent,
+	},
+	{
+		From:        3,
+		Description: "derive each task's status from whether it was completed",
+		Apply:       migrateStatus,
+	},
+	{
+		From:        4,
+		Description: "give every task a UUID",
+		Apply:       migrateUUIDs,
+	},
+}
+
+// migrateTimeSpent replaces the time_spent minutes of each task with a single
+// legacy time entry of that length. The entry ends when the task was
+// completed, or when it was created if it is still pending.
+func migrateTimeSpent(tasks []map[string]any) error {
+	for _, task := range tasks {
+		raw, ok := task["time_spent"]
+		delete(task, "time_spent")
+		if !ok || raw == nil {
+			continue
+		}
+		number, ok := raw.(json.Number)
+		if !ok {
+			return fmt.Errorf("task %v: time_spent is not a number", task["id"])
+		}
+		minutes, parseErr := number.Int64()
+		if parseErr != nil {
+			return fmt.Errorf("task %v: invalid time_spent: %w", task["id"], parseErr)
+		}
+		if minutes <= 0 {
+			continue
+		}
+
+		end, endErr := rawTime(task, "completed_at")
+		if endErr == nil && end.IsZero() {
+			end, endErr = rawTime(task, "created_at")
+		}
+		if endErr != nil {
+			return fmt.Errorf("task %v: %w", task["id"], endErr)
+		}
+		start := end.Add(-time.Duration(minutes) * time.Minute)
+		task["time_entries"] = []any{map[string]any{
+			"start":  start.Format(time.RFC3339Nano),
+			"end":    end.Format(time.RFC3339Nano),
+			"legacy": true,
+		}}
+	}
+	return nil
+}
+
+// migrateStatus sets the status of each task: done if it was completed,
+// pending otherwise.
+func migrateStatus(tasks []map[string]any) error {
+	for _, task := range tasks {
+		completedAt, err := rawTime(task, "completed_at")
+		if err != nil {
+			return fmt.Errorf("task %v: %w", task["id"], err)
+		}
+		if completedAt.IsZero() {
+			task["status"] = string(model.StatusPending)
+		} else {
+			task["status"] = string(model.StatusDone)
+		}
+	}
+	return nil
+}
+
+// migrateUUIDs assigns a UUID to each task that has none. Until the upgrade
+// is saved the file is migrated again every time it is loaded, so the UUID is
+// derived from the task's ID, creation time and title rather than drawn at
+// random: every load, in every process, hands out the same UUID.
+func migrateUUIDs(tasks []map[string]any) error {
+	for _, task := range tasks {
+		if uuid, _ := task["uuid"].(string); uuid == "" {
+			task["uuid"] = legacyUUID(task)
+		}
+	}
+	return nil
+}
+
+// legacyUUID returns the name-based (version 5 style) UUID of a task stored
+// without one.
+func legacyUUID(task map[string]any) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("task:%v:%v:%v", task["id"], task["created_at"], task["title"])))
+	b := sum[:16]
+	b[6] = b[6]&0x0f | 0x50
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// rawTime reads a timestamp field of an undecoded task. A missing field is
+// the zero time.
+func rawTime(task map[string]any, field string) (time.Time, error) {
+	value, ok := task[field].(string)
+	if !ok {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %w", field, err)
+	}
+	return t, nil
+}
+
+// MigrationPlan describes how a store would be, or was, upgraded.
+type MigrationPlan struct {
+	FromVersion int
+	ToVersion   int
+	Steps       []Migration
+	TaskCount   int
+	// BackupPath is where the original data is copied before it is rewritten.
+	BackupPath string
+}
+
+// Migrator is implemented by stores that can upgrade data written by older
+// versions of task.
+type Migrator interface {
+	// MigrationPlan returns the pending upgrade, or nil if the store is
+	// already at CurrentSchemaVersion.
+	MigrationPlan() *MigrationPlan
+
+	// Migrate backs up the original data and persists it in the current
+	// format. It returns the plan that was applied, or nil if there was
+	// nothing to do.
+	Migrate() (*MigrationPlan, error)
+}
+
+// Upgrader is implemented by stores that upgrade data written by older
+// versions of task as soon as they are opened, so nothing is ever pending.
+type Upgrader interface {
+	// Upgraded returns the upgrade applied when the store was opened, or nil
+	// if it was already at CurrentSchemaVersion.
+	Upgraded() *MigrationPlan
+}
+
+// migrationsFrom returns the migrations needed to upgrade from version.
+func migrationsFrom(version int) ([]Migration, error) {
+	if version > CurrentSchemaVersion {
+		return nil, fmt.Errorf("%w (version %d, this build supports up to %d)", ErrNewerSchema, version, CurrentSchemaVersion)
+	}
+
+	var pending []Migration
+	for _, m := range migrations {
+		if m.From >= version {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// migrateTasks applies steps to raw tasks and decodes the result.
+func migrateTasks(raw []map[string]any, steps []Migration) ([]*model.Task, error) {
+	for _, step := range steps {
+		if step.Apply == nil {
+			continue
+		}
+		if applyErr := step.Apply(raw); applyErr != nil {
+			return nil, fmt.Errorf("migration from version %d failed: %w", step.From, applyErr)
+		}
+	}
+
+	data, marshErr := json.Marshal(raw)
+	if marshErr != nil {
+		return nil, fmt.Errorf("failed to marshal migrated tasks: %w", marshErr)
+	}
+	var tasks []*model.Task
+	if unMarshalErr := json.Unmarshal(data, &tasks); unMarshalErr != nil {
+		return nil, fmt.Errorf("failed to unmarshal migrated tasks: %w", unMarshalErr)
+	}
+	return tasks, nil
+}
+
+// decodeRaw decodes JSON into generic values, keeping numbers exact.
+func decodeRaw(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// taskFile is the versioned envelope the JSON store is persisted in.
+type taskFile struct {
+	Version int `json:"version"`
+	// NextID is the ID the next added task gets. It is kept so that the ID
+	// of a removed task is never given to another one.
+	NextID int           `json:"next_id,omitempty"`
+	Tasks  []*model.Task `json:"tasks"`
+}
+
+// decodeTaskFile parses a task file of any known version. It returns the
+// file with its tasks upgraded to the current schema but Version still set
+// to the version found on disk, and the migrations that were applied in
+// memory.
+func decodeTaskFile(data []byte) (*taskFile, []Migration, error) {
+	var probe map[string]json.RawMessage
+	if probeErr := json.Unmarshal(data, &probe); probeErr != nil {
+		return nil, nil, probeErr
+	}
+
+	file := &taskFile{Version: legacySchemaVersion}
+	var raw []map[string]any
+	if rawVersion, ok := probe["version"]; ok {
+		if versionErr := json.Unmarshal(rawVersion, &file.Version); versionErr != nil {
+			return nil, nil, fmt.Errorf("invalid schema version: %w", versionErr)
+		}
+		if file.Version == CurrentSchemaVersion {
+			if unMarshalErr := json.Unmarshal(data, file); unMarshalErr != nil {
+				return nil, nil, unMarshalErr
+			}
+			return file, nil, nil
+		}
+		if rawNextID, ok := probe["next_id"]; ok {
+			if nextIDErr := json.Unmarshal(rawNextID, &file.NextID); nextIDErr != nil {
+				return nil, nil, fmt.Errorf("invalid next_id: %w", nextIDErr)
+			}
+		}
+		if rawTasks, ok := probe["tasks"]; ok {
+			if decodeErr := decodeRaw(rawTasks, &raw); decodeErr != nil {
+				return nil, nil, decodeErr
+			}
+		}
+	} else {
+		// Version 1 files are a bare map keyed by task ID.
+		for key, rawTask := range probe {
+			if _, atoiErr := strconv.Atoi(key); atoiErr != nil {
+				return nil, nil, fmt.Errorf("unexpected key %q in unversioned task file", key)
+			}
+			var task map[string]any
+			if decodeErr := decodeRaw(rawTask, &task); decodeErr != nil {
+				return nil, nil, decodeErr
+			}
+			raw = append(raw, task)
+		}
+	}
+
+	steps, stepsErr := migrationsFrom(file.Version)
+	if stepsErr != nil {
+		return nil, nil, stepsErr
+	}
+	tasks, migrateErr := migrateTasks(raw, steps)
+	if migrateErr != nil {
+		return nil, nil, migrateErr
+	}
+	file.Tasks = tasks
+	return file, steps, nil
+}
+
+// backupPath returns where the original data of a store at the given schema
+// version is copied before being migrated.
+func backupPath(filename string, version int) string {
+	return fmt.Sprintf("%s.v%d.bak", filename, version)
+}
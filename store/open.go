@@ -0,0 +1,27 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Supported storage backends.
+const (
+	BackendJSON   = "json"
+	BackendSQLite = "sqlite"
+)
+
+// errUnknownBackend is returned by Open for an unsupported backend name.
+var errUnknownBackend = errors.New("unknown store backend")
+
+// Open creates the TaskRepository for the named backend, persisting to filename.
+func Open(backend string, filename string, opts ...Option) (TaskRepository, error) {
+	switch backend {
+	case BackendJSON, "":
+		return NewJsonStore(filename, opts...)
+	case BackendSQLite:
+		return NewSQLiteStore(filename, opts...)
+	default:
+		return nil, fmt.Errorf("%w: %q (expected %q or %q)", errUnknownBackend, backend, BackendJSON, BackendSQLite)
+	}
+}
@@ -0,0 +1,28 @@
+package store
+
+import "time"
+
+// options holds the settings shared by all store backends.
+type options struct {
+	lockTimeout time.Duration
+}
+
+// Option configures optional store behaviour.
+type Option func(*options)
+
+// WithLockTimeout sets how long the store waits for another process to
+// release the store lock before failing.
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.lockTimeout = timeout
+	}
+}
+
+// newOptions applies opts on top of the defaults.
+func newOptions(opts []Option) options {
+	o := options{lockTimeout: DefaultLockTimeout}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -0,0 +1,301 @@
+package store
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kevin7254/task/model"
+)
+
+const (
+	// snapshotPrefix starts the name of every snapshot file.
+	snapshotPrefix = "tasks-"
+	// snapshotExt ends the name of snapshots written by WriteSnapshot.
+	// Uncompressed ".json" snapshots from earlier versions are still read.
+	snapshotExt = ".json.gz"
+	// snapshotTimeFormat is the timestamp in snapshot file names. It sorts in
+	// the order the snapshots were taken.
+	snapshotTimeFormat = "20060102-150405"
+)
+
+// SnapshotInfo describes a snapshot file.
+type SnapshotInfo struct {
+	// Name is the file name without its extension, as accepted by
+	// FindSnapshot.
+	Name  string
+	Path  string
+	Taken time.Time
+	Size  int64
+}
+
+// WriteSnapshot saves a compressed copy of every task in repo to a new file
+// in dir, named after when it was taken, and returns the file's path.
+// Snapshots hold the JSON store's file format whatever the backend of repo.
+func WriteSnapshot(dir string, repo TaskRepository, now time.Time) (string, error) {
+	tasks, listErr := ListTasks(repo)
+	if listErr != nil {
+		return "", listErr
+	}
+	slices.SortFunc(tasks, func(a, b *model.Task) int { return a.ID - b.ID })
+	data, marshalErr := json.Marshal(taskFile{Version: CurrentSchemaVersion, Tasks: tasks})
+	if marshalErr != nil {
+		return "", fmt.Errorf("failed to marshal snapshot: %w", marshalErr)
+	}
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, writeErr := zw.Write(data); writeErr != nil {
+		return "", fmt.Errorf("failed to compress snapshot: %w", writeErr)
+	}
+	if closeErr := zw.Close(); closeErr != nil {
+		return "", fmt.Errorf("failed to compress snapshot: %w", closeErr)
+	}
+
+	if mkdirErr := os.MkdirAll(dir, 0755); mkdirErr != nil {
+		return "", fmt.Errorf("failed to create snapshot directory: %w", mkdirErr)
+	}
+	path, pathErr := snapshotPath(dir, now)
+	if pathErr != nil {
+		return "", pathErr
+	}
+	if writeErr := writeFileAtomic(path, compressed.Bytes(), 0644); writeErr != nil {
+		return "", fmt.Errorf("failed to write snapshot: %w", writeErr)
+	}
+	return path, nil
+}
+
+// snapshotPath returns a file name in dir for a snapshot taken at now that
+// no earlier snapshot uses.
+func snapshotPath(dir string, now time.Time) (string, error) {
+	base := snapshotPrefix + now.Format(snapshotTimeFormat)
+	for n := 1; ; n++ {
+		name := base
+		if n > 1 {
+			name += "-" + strconv.Itoa(n)
+		}
+		taken := false
+		for _, ext := range []string{snapshotExt, ".json"} {
+			if _, statErr := os.Stat(filepath.Join(dir, name+ext)); statErr == nil {
+				taken = true
+			} else if !errors.Is(statErr, os.ErrNotExist) {
+				return "", fmt.Errorf("failed to check snapshot %s: %w", name, statErr)
+			}
+		}
+		if !taken {
+			return filepath.Join(dir, name+snapshotExt), nil
+		}
+	}
+}
+
+// ListSnapshots returns the snapshots in dir, newest first. A missing
+// directory has no snapshots.
+func ListSnapshots(dir string) ([]SnapshotInfo, error) {
+	entries, readErr := os.ReadDir(dir)
+	if errors.Is(readErr, os.ErrNotExist) {
+		return nil, nil
+	}
+	if readErr != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", readErr)
+	}
+
+	var snapshots []SnapshotInfo
+	for _, entry := range entries {
+		name, ok := snapshotName(entry.Name())
+		if !ok || entry.IsDir() {
+			continue
+		}
+		stamp := strings.TrimPrefix(name, snapshotPrefix)
+		if len(stamp) < len(snapshotTimeFormat) {
+			continue
+		}
+		taken, parseErr := time.ParseInLocation(snapshotTimeFormat, stamp[:len(snapshotTimeFormat)], time.Local)
+		if parseErr != nil {
+			continue
+		}
+		info, infoErr := entry.Info()
+		if infoErr != nil {
+			return nil, fmt.Errorf("failed to list snapshots: %w", infoErr)
+		}
+		snapshots = append(snapshots, SnapshotInfo{
+			Name:  name,
+			Path:  filepath.Join(dir, entry.Name()),
+			Taken: taken,
+			Size:  info.Size(),
+		})
+	}
+	// Names sort in the order the snapshots were taken, including those
+	// taken in the same second.
+	slices.SortFunc(snapshots, func(a, b SnapshotInfo) int {
+		if a.Taken.Equal(b.Taken) {
+			return compareNumbered(b.Name, a.Name)
+		}
+		return b.Taken.Compare(a.Taken)
+	})
+	return snapshots, nil
+}
+
+// compareNumbered compares snapshot names taken in the same second, where
+// "tasks-X-10" comes after "tasks-X-2".
+func compareNumbered(a, b string) int {
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return strings.Compare(a, b)
+}
+
+// snapshotName returns the name of a snapshot file without its extension,
+// and whether file is a snapshot at all.
+func snapshotName(file string) (string, bool) {
+	if !strings.HasPrefix(file, snapshotPrefix) {
+		return "", false
+	}
+	for _, ext := range []string{snapshotExt, ".json"} {
+		if name, ok := strings.CutSuffix(file, ext); ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// FindSnapshot returns the path of the snapshot ref refers to: a path to a
+// snapshot file, or the name of a snapshot in dir as listed by
+// ListSnapshots.
+func FindSnapshot(dir string, ref string) (string, error) {
+	if info, statErr := os.Stat(ref); statErr == nil && !info.IsDir() {
+		return ref, nil
+	}
+	for _, ext := range []string{"", snapshotExt, ".json"} {
+		path := filepath.Join(dir, ref+ext)
+		if info, statErr := os.Stat(path); statErr == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("snapshot %s not found in %s", ref, dir)
+}
+
+// PruneSnapshots removes all but the newest keep snapshots in dir and
+// returns the paths it removed. A keep of zero or less keeps every
+// snapshot.
+func PruneSnapshots(dir string, keep int) ([]string, error) {
+	if keep <= 0 {
+		return nil, nil
+	}
+	snapshots, listErr := ListSnapshots(dir)
+	if listErr != nil {
+		return nil, listErr
+	}
+	var removed []string
+	for i := keep; i < len(snapshots); i++ {
+		if removeErr := os.Remove(snapshots[i].Path); removeErr != nil {
+			return removed, fmt.Errorf("failed to remove old snapshot: %w", removeErr)
+		}
+		removed = append(removed, snapshots[i].Path)
+	}
+	return removed, nil
+}
+
+// ReadSnapshot reads and validates the tasks saved in a snapshot, upgrading
+// them to the current schema if the snapshot was written by an older
+// version.
+func ReadSnapshot(path string) ([]*model.Task, error) {
+	f, osErr := os.Open(path)
+	if osErr != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", osErr)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		zr, gzipErr := gzip.NewReader(f)
+		if gzipErr != nil {
+			return nil, fmt.Errorf("invalid snapshot %s: %w", path, gzipErr)
+		}
+		defer zr.Close()
+		r = zr
+	}
+	data, readErr := io.ReadAll(r)
+	if readErr != nil {
+		return nil, fmt.Errorf("invalid snapshot %s: %w", path, readErr)
+	}
+
+	file, _, decodeErr := decodeTaskFile(data)
+	if decodeErr != nil {
+		return nil, fmt.Errorf("invalid snapshot %s: %w", path, decodeErr)
+	}
+	if validateErr := validateSnapshot(file.Tasks); validateErr != nil {
+		return nil, fmt.Errorf("invalid snapshot %s: %w", path, validateErr)
+	}
+	return file.Tasks, nil
+}
+
+// validateSnapshot checks that tasks can be stored side by side: every task
+// has a positive ID and a UUID, and no two tasks share either.
+func validateSnapshot(tasks []*model.Task) error {
+	ids := make(map[int]bool, len(tasks))
+	uuids := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		switch {
+		case task == nil:
+			return fmt.Errorf("empty task entry")
+		case task.ID <= 0:
+			return fmt.Errorf("task %q has invalid ID %d", task.Title, task.ID)
+		case ids[task.ID]:
+			return fmt.Errorf("task ID %d is used twice", task.ID)
+		case task.UUID == "":
+			return fmt.Errorf("task %d has no UUID", task.ID)
+		case uuids[task.UUID]:
+			return fmt.Errorf("UUID %s is used twice", task.UUID)
+		}
+		ids[task.ID] = true
+		uuids[task.UUID] = true
+	}
+	return nil
+}
+
+// ReplaceTasks makes tasks the contents of repo. Tasks that are unchanged
+// are left alone, so that a Recorder only records the differences.
+func ReplaceTasks(repo TaskRepository, tasks []*model.Task) error {
+	wanted := make(map[int]*model.Task, len(tasks))
+	for _, task := range tasks {
+		wanted[task.ID] = task
+	}
+
+	existing, listErr := ListTasks(repo)
+	if listErr != nil {
+		return listErr
+	}
+	current := make(map[int]*model.Task)
+	for _, task := range existing {
+		if want, ok := wanted[task.ID]; ok && want.UUID == task.UUID {
+			current[task.ID] = task
+			continue
+		}
+		if deleteErr := repo.DeleteTask(task.ID); deleteErr != nil {
+			return fmt.Errorf("failed to remove task %d: %w", task.ID, deleteErr)
+		}
+	}
+
+	for _, task := range tasks {
+		stored, ok := current[task.ID]
+		switch {
+		case !ok:
+			if restoreErr := repo.RestoreTask(task); restoreErr != nil {
+				return fmt.Errorf("failed to restore task %d: %w", task.ID, restoreErr)
+			}
+		case !sameTask(stored, task):
+			if updateErr := repo.UpdateTask(task); updateErr != nil {
+				return fmt.Errorf("failed to restore task %d: %w", task.ID, updateErr)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kevin7254/task/model"
+)
+
+func TestSnapshot_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewSQLiteStore(filepath.Join(dir, "tasks.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+	for _, title := range []string{"First", "Second"} {
+		if err := s.AddTask(&model.Task{Title: title}); err != nil {
+			t.Fatalf("AddTask failed: %v", err)
+		}
+	}
+
+	backups := filepath.Join(dir, "backups")
+	now := time.Date(2025, 6, 3, 9, 30, 0, 0, time.Local)
+	path, err := WriteSnapshot(backups, s, now)
+	if err != nil {
+		t.Fatalf("WriteSnapshot failed: %v", err)
+	}
+	if want := filepath.Join(backups, "tasks-20250603-093000.json.gz"); path != want {
+		t.Errorf("Expected snapshot at %q, got %q", want, path)
+	}
+	second, err := WriteSnapshot(backups, s, now)
+	if err != nil {
+		t.Fatalf("WriteSnapshot failed: %v", err)
+	}
+	if second == path {
+		t.Error("Expected a second snapshot in the same second not to overwrite the first")
+	}
+
+	tasks, err := ReadSnapshot(path)
+	if err != nil {
+		t.Fatalf("ReadSnapshot failed: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].Title != "First" || tasks[1].UUID == "" {
+		t.Errorf("Expected both tasks with their UUIDs, got %+v", tasks)
+	}
+
+	snapshots, err := ListSnapshots(backups)
+	if err != nil {
+		t.Fatalf("ListSnapshots failed: %v", err)
+	}
+	if len(snapshots) != 2 || snapshots[0].Path != second || !snapshots[1].Taken.Equal(now) {
+		t.Errorf("Expected both snapshots, newest first, got %+v", snapshots)
+	}
+	if found, err := FindSnapshot(backups, snapshots[1].Name); err != nil || found != path {
+		t.Errorf("Expected to find %s by name, got %q (%v)", snapshots[1].Name, found, err)
+	}
+
+	removed, err := PruneSnapshots(backups, 1)
+	if err != nil || len(removed) != 1 || removed[0] != path {
+		t.Errorf("Expected pruning to remove the oldest snapshot, got %v (%v)", removed, err)
+	}
+
+	if err := os.WriteFile(second, []byte("not gzip"), 0644); err != nil {
+		t.Fatalf("Failed to corrupt snapshot: %v", err)
+	}
+	if _, err := ReadSnapshot(second); err == nil {
+		t.Error("Expected a corrupt snapshot to be rejected")
+	}
+}
+
+func TestSnapshot_RejectsDuplicateIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks-20250603-093000.json")
+	data := `{"version": 5, "tasks": [{"id": 1, "uuid": "a", "title": "One"}, {"id": 1, "uuid": "b", "title": "Two"}]}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write snapshot: %v", err)
+	}
+	if _, err := ReadSnapshot(path); err == nil {
+		t.Error("Expected a snapshot with a duplicate ID to be rejected")
+	}
+}
+
+func TestReplaceTasks(t *testing.T) {
+	r, s := newTestRecorder(t)
+	for _, title := range []string{"Keep", "Change", "Drop"} {
+		if err := s.AddTask(&model.Task{Title: title}); err != nil {
+			t.Fatalf("AddTask failed: %v", err)
+		}
+	}
+	changed := s.GetTaskByID(2)
+	changed.Title = "Changed back"
+	snapshot := []*model.Task{s.GetTaskByID(1), changed, {ID: 7, UUID: model.NewUUID(), Title: "Restored"}}
+
+	r.Begin("restore")
+	if err := ReplaceTasks(r, snapshot); err != nil {
+		t.Fatalf("ReplaceTasks failed: %v", err)
+	}
+	if s.GetTaskByID(2).Title != "Changed back" || s.GetTaskByID(3) != nil || s.GetTaskByID(7) == nil {
+		t.Errorf("Expected the store to hold the snapshot, got %v", s.ListAllTasks())
+	}
+	ops, err := r.History().Operations()
+	if err != nil {
+		t.Fatalf("Operations failed: %v", err)
+	}
+	if len(ops) != 1 || len(ops[0].Changes) != 3 {
+		t.Errorf("Expected one operation changing tasks 2, 3 and 7, got %+v", ops)
+	}
+}
@@ -0,0 +1,420 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kevin7254/task/model"
+	_ "modernc.org/sqlite" // pure-Go SQLite driver, registered as "sqlite"
+)
+
+// sqliteSchema creates the tasks table. The full task is kept as JSON in the
+// data column, while the columns used for filtering and sorting are mirrored
+// into indexed columns.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS tasks (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	project      TEXT    NOT NULL DEFAULT '',
+	priority     INTEGER NOT NULL DEFAULT 0,
+	due_date     INTEGER,
+	completed_at INTEGER,
+	data         TEXT    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_tasks_project ON tasks(project);
+CREATE INDEX IF NOT EXISTS idx_tasks_priority ON tasks(priority);
+CREATE INDEX IF NOT EXISTS idx_tasks_due_date ON tasks(due_date);
+CREATE INDEX IF NOT EXISTS idx_tasks_completed_at ON tasks(completed_at);
+`
+
+// SQLiteStore implements the TaskRepository on top of an SQLite database.
+// Unlike JsonStore it only touches the rows that change, so it stays fast
+// with thousands of tasks. Databases written by older versions are backed up
+// and upgraded when they are opened.
+type SQLiteStore struct {
+	db       *sql.DB
+	upgraded *MigrationPlan
+}
+
+// NewSQLiteStore opens (or creates) the SQLite database at filename and makes
+// sure the schema exists.
+func NewSQLiteStore(filename string, opts ...Option) (*SQLiteStore, error) {
+	if osErr := os.MkdirAll(filepath.Dir(filename), 0755); osErr != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", osErr)
+	}
+
+	options := newOptions(opts)
+	query := url.Values{}
+	query.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", options.lockTimeout.Milliseconds()))
+	query.Add("_pragma", "journal_mode(WAL)")
+	query.Add("_pragma", "synchronous(FULL)")
+	dsn := "file:" + filename + "?" + query.Encode()
+
+	db, openErr := sql.Open("sqlite", dsn)
+	if openErr != nil {
+		return nil, fmt.Errorf("failed to open database: %w", openErr)
+	}
+	sqliteStore := &SQLiteStore{db: db}
+	if schemaErr := sqliteStore.initSchema(filename); schemaErr != nil {
+		_ = db.Close()
+		return nil, schemaErr
+	}
+
+	return sqliteStore, nil
+}
+
+// sqliteFirstSchemaVersion is the task schema version of databases created
+// before the schema version was recorded in PRAGMA user_version.
+const sqliteFirstSchemaVersion = 2
+
+// initSchema creates the tables of a new database, or upgrades the tasks of
+// a database written by an older version after backing it up.
+func (s *SQLiteStore) initSchema(filename string) error {
+	var existing int
+	if queryErr := s.db.QueryRow(`SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'tasks'`).Scan(&existing); queryErr != nil {
+		return fmt.Errorf("failed to inspect schema: %w", queryErr)
+	}
+	var version int
+	if queryErr := s.db.QueryRow(`PRAGMA user_version`).Scan(&version); queryErr != nil {
+		return fmt.Errorf("failed to read schema version: %w", queryErr)
+	}
+
+	if existing == 0 {
+		if _, execErr := s.db.Exec(sqliteSchema); execErr != nil {
+			return fmt.Errorf("failed to create schema: %w", execErr)
+		}
+		return s.setSchemaVersion(s.db, CurrentSchemaVersion)
+	}
+
+	if version == 0 {
+		version = sqliteFirstSchemaVersion
+	}
+	steps, stepsErr := migrationsFrom(version)
+	if stepsErr != nil {
+		return stepsErr
+	}
+	if len(steps) == 0 {
+		return nil
+	}
+	return s.migrate(filename, version, steps)
+}
+
+// migrate copies the database to its backup path and rewrites every task
+// through steps in a single transaction.
+func (s *SQLiteStore) migrate(filename string, version int, steps []Migration) error {
+	if _, execErr := s.db.Exec(`VACUUM INTO ?`, backupPath(filename, version)); execErr != nil {
+		return fmt.Errorf("failed to back up database before migration: %w", execErr)
+	}
+
+	tx, txErr := s.db.Begin()
+	if txErr != nil {
+		return fmt.Errorf("failed to start migration: %w", txErr)
+	}
+	defer tx.Rollback()
+
+	rows, queryErr := tx.Query(`SELECT id, data FROM tasks ORDER BY id`)
+	if queryErr != nil {
+		return fmt.Errorf("failed to read tasks for migration: %w", queryErr)
+	}
+	var raw []map[string]any
+	for rows.Next() {
+		var (
+			id   int
+			data string
+		)
+		if scanErr := rows.Scan(&id, &data); scanErr != nil {
+			_ = rows.Close()
+			return scanErr
+		}
+		var task map[string]any
+		if decodeErr := decodeRaw([]byte(data), &task); decodeErr != nil {
+			_ = rows.Close()
+			return fmt.Errorf("failed to decode task for migration: %w", decodeErr)
+		}
+		// As in scanTask, the row ID is authoritative; the ID inside data
+		// may be the 0 the task had before it was inserted.
+		task["id"] = id
+		raw = append(raw, task)
+	}
+	if closeErr := rows.Close(); closeErr != nil {
+		return closeErr
+	}
+
+	tasks, migrateErr := migrateTasks(raw, steps)
+	if migrateErr != nil {
+		return migrateErr
+	}
+	for _, t := range tasks {
+		args, argsErr := taskColumns(t)
+		if argsErr != nil {
+			return argsErr
+		}
+		if _, execErr := tx.Exec(
+			`UPDATE tasks SET project = ?, priority = ?, due_date = ?, completed_at = ?, data = ? WHERE id = ?`,
+			args...,
+		); execErr != nil {
+			return fmt.Errorf("failed to write migrated task %d: %w", t.ID, execErr)
+		}
+	}
+	if versionErr := s.setSchemaVersion(tx, CurrentSchemaVersion); versionErr != nil {
+		return versionErr
+	}
+	if commitErr := tx.Commit(); commitErr != nil {
+		return commitErr
+	}
+
+	s.upgraded = &MigrationPlan{
+		FromVersion: version,
+		ToVersion:   CurrentSchemaVersion,
+		Steps:       steps,
+		TaskCount:   len(tasks),
+		BackupPath:  backupPath(filename, version),
+	}
+	return nil
+}
+
+// Upgraded returns the upgrade applied when the database was opened, or nil
+// if it was already in the current format.
+func (s *SQLiteStore) Upgraded() *MigrationPlan {
+	return s.upgraded
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// setSchemaVersion records the task schema version in the database header.
+func (s *SQLiteStore) setSchemaVersion(db execer, version int) error {
+	// PRAGMA statements cannot take bound parameters.
+	if _, execErr := db.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, version)); execErr != nil {
+		return fmt.Errorf("failed to record schema version: %w", execErr)
+	}
+	return nil
+}
+
+// Close releases the underlying database handle.
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// ListAllTasks returns all tasks in the store. Rows that cannot be read are
+// left out; ListTasks reports them.
+func (s *SQLiteStore) ListAllTasks() []*model.Task {
+	tasks, _ := s.listTasks()
+	return tasks
+}
+
+// ListTasks returns all tasks in the store, or an error if the tasks cannot
+// be queried or any row cannot be decoded.
+func (s *SQLiteStore) ListTasks() ([]*model.Task, error) {
+	tasks, listErr := s.listTasks()
+	if listErr != nil {
+		return nil, listErr
+	}
+	return tasks, nil
+}
+
+// listTasks returns the tasks that could be read, together with the first
+// error met while reading them.
+func (s *SQLiteStore) listTasks() ([]*model.Task, error) {
+	rows, queryErr := s.db.Query(`SELECT id, data FROM tasks ORDER BY id`)
+	if queryErr != nil {
+		return []*model.Task{}, fmt.Errorf("failed to list tasks: %w", queryErr)
+	}
+	defer rows.Close()
+
+	tasks := make([]*model.Task, 0)
+	var firstErr error
+	for rows.Next() {
+		task, scanErr := scanTask(rows)
+		if scanErr != nil {
+			if firstErr == nil {
+				firstErr = scanErr
+			}
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	if rowsErr := rows.Err(); rowsErr != nil && firstErr == nil {
+		firstErr = fmt.Errorf("failed to list tasks: %w", rowsErr)
+	}
+	return tasks, firstErr
+}
+
+// GetTaskByID retrieves a task by its ID.
+// Returns nil if no task with the given ID exists.
+func (s *SQLiteStore) GetTaskByID(id int) *model.Task {
+	row := s.db.QueryRow(`SELECT id, data FROM tasks WHERE id = ?`, id)
+	task, scanErr := scanTask(row)
+	if scanErr != nil {
+		return nil
+	}
+	return task
+}
+
+// AddTask adds a task to the store and assigns it a unique ID, and a UUID if
+// it has none. Returns an error if the operation fails.
+func (s *SQLiteStore) AddTask(t *model.Task) error {
+	if t.UUID == "" {
+		t.UUID = model.NewUUID()
+	}
+	args, argsErr := taskColumns(t)
+	if argsErr != nil {
+		return argsErr
+	}
+
+	tx, txErr := s.db.Begin()
+	if txErr != nil {
+		return fmt.Errorf("failed to insert task: %w", txErr)
+	}
+	defer tx.Rollback()
+
+	// The trailing ID argument is assigned by the database.
+	result, execErr := tx.Exec(
+		`INSERT INTO tasks (project, priority, due_date, completed_at, data) VALUES (?, ?, ?, ?, ?)`,
+		args[:len(args)-1]...,
+	)
+	if execErr != nil {
+		return fmt.Errorf("failed to insert task: %w", execErr)
+	}
+	id, idErr := result.LastInsertId()
+	if idErr != nil {
+		return fmt.Errorf("failed to read new task ID: %w", idErr)
+	}
+
+	// Store the data again now that it can hold the ID.
+	added := t.Clone()
+	added.ID = int(id)
+	data, marshErr := json.Marshal(added)
+	if marshErr != nil {
+		return fmt.Errorf("failed to marshal task: %w", marshErr)
+	}
+	if _, execErr := tx.Exec(`UPDATE tasks SET data = ? WHERE id = ?`, string(data), id); execErr != nil {
+		return fmt.Errorf("failed to insert task: %w", execErr)
+	}
+	if commitErr := tx.Commit(); commitErr != nil {
+		return fmt.Errorf("failed to insert task: %w", commitErr)
+	}
+	t.ID = added.ID
+	return nil
+}
+
+// RestoreTask adds a previously removed task back under its original ID.
+// Returns an error if a task with that ID already exists.
+func (s *SQLiteStore) RestoreTask(t *model.Task) error {
+	args, argsErr := taskColumns(t)
+	if argsErr != nil {
+		return argsErr
+	}
+
+	// Count the row rather than reading the task, so that a row that
+	// cannot be decoded still counts as taken.
+	var existing int
+	if queryErr := s.db.QueryRow(`SELECT count(*) FROM tasks WHERE id = ?`, t.ID).Scan(&existing); queryErr != nil {
+		return fmt.Errorf("failed to restore task: %w", queryErr)
+	}
+	if existing > 0 {
+		return fmt.Errorf("task with ID %d already exists", t.ID)
+	}
+	if _, execErr := s.db.Exec(
+		`INSERT INTO tasks (project, priority, due_date, completed_at, data, id) VALUES (?, ?, ?, ?, ?, ?)`,
+		args...,
+	); execErr != nil {
+		return fmt.Errorf("failed to restore task: %w", execErr)
+	}
+	return nil
+}
+
+// UpdateTask updates an existing task.
+// Returns an error if the task doesn't exist, its UUID would change or the
+// operation fails.
+func (s *SQLiteStore) UpdateTask(t *model.Task) error {
+	args, argsErr := taskColumns(t)
+	if argsErr != nil {
+		return argsErr
+	}
+
+	if existing := s.GetTaskByID(t.ID); existing != nil && existing.UUID != t.UUID {
+		return fmt.Errorf("%w (task %d)", ErrUUIDChanged, t.ID)
+	}
+
+	result, execErr := s.db.Exec(
+		`UPDATE tasks SET project = ?, priority = ?, due_date = ?, completed_at = ?, data = ? WHERE id = ?`,
+		args...,
+	)
+	if execErr != nil {
+		return fmt.Errorf("failed to update task: %w", execErr)
+	}
+	return requireAffected(result, t.ID)
+}
+
+// DeleteTask removes a task from the store.
+// Returns an error if the task doesn't exist or the operation fails.
+func (s *SQLiteStore) DeleteTask(id int) error {
+	result, execErr := s.db.Exec(`DELETE FROM tasks WHERE id = ?`, id)
+	if execErr != nil {
+		return fmt.Errorf("failed to delete task: %w", execErr)
+	}
+	return requireAffected(result, id)
+}
+
+// taskColumns returns the values of the project, priority, due_date,
+// completed_at, data and id columns for t, in that order.
+func taskColumns(t *model.Task) ([]any, error) {
+	data, marshErr := json.Marshal(t)
+	if marshErr != nil {
+		return nil, fmt.Errorf("failed to marshal task: %w", marshErr)
+	}
+	return []any{t.Project, int(t.Priority), sqliteTime(t.DueDate), sqliteTime(t.CompletedAt), string(data), t.ID}, nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTask decodes a task from a row holding its ID and JSON data.
+func scanTask(row rowScanner) (*model.Task, error) {
+	var (
+		id   int
+		data string
+	)
+	if scanErr := row.Scan(&id, &data); scanErr != nil {
+		return nil, scanErr
+	}
+
+	task := &model.Task{}
+	if unMarshalErr := json.Unmarshal([]byte(data), task); unMarshalErr != nil {
+		return nil, fmt.Errorf("failed to unmarshal task %d: %w", id, unMarshalErr)
+	}
+	// The row ID is authoritative; databases written by older versions
+	// hold the ID 0 in the data of tasks added to them.
+	task.ID = id
+	return task, nil
+}
+
+// requireAffected reports a not-found error when a statement touched no rows.
+func requireAffected(result sql.Result, id int) error {
+	affected, affectedErr := result.RowsAffected()
+	if affectedErr != nil {
+		return fmt.Errorf("failed to check affected rows: %w", affectedErr)
+	}
+	if affected == 0 {
+		return fmt.Errorf("task with ID %d does not exist", id)
+	}
+	return nil
+}
+
+// sqliteTime converts a time to the value stored in an indexed date column.
+// Zero times are stored as NULL so they never sort as real dates.
+func sqliteTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UnixNano()
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kevin7254/task/model"
+)
+
+func TestSQLiteStore_StoresIDInData(t *testing.T) {
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "tasks.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	for _, title := range []string{"First", "Second"} {
+		if err := s.AddTask(&model.Task{Title: title}); err != nil {
+			t.Fatalf("AddTask failed: %v", err)
+		}
+	}
+	var data string
+	if err := s.db.QueryRow(`SELECT data FROM tasks WHERE id = 2`).Scan(&data); err != nil {
+		t.Fatalf("Failed to read task data: %v", err)
+	}
+	var stored model.Task
+	if err := json.Unmarshal([]byte(data), &stored); err != nil {
+		t.Fatalf("Failed to decode task data: %v", err)
+	}
+	if stored.ID != 2 {
+		t.Errorf("Expected the data of task 2 to hold its ID, got %d", stored.ID)
+	}
+}
+
+func TestSQLiteStore_ReportsUndecodableRows(t *testing.T) {
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "tasks.db"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	for _, title := range []string{"Readable", "Broken"} {
+		if err := s.AddTask(&model.Task{Title: title}); err != nil {
+			t.Fatalf("AddTask failed: %v", err)
+		}
+	}
+	readable := s.GetTaskByID(1)
+	if _, err := s.db.Exec(`UPDATE tasks SET data = '{' WHERE id = 2`); err != nil {
+		t.Fatalf("Failed to corrupt task 2: %v", err)
+	}
+
+	if got := len(s.ListAllTasks()); got != 1 {
+		t.Errorf("Expected ListAllTasks to leave out the broken task, got %d tasks", got)
+	}
+	if _, err := ListTasks(s); err == nil || !strings.Contains(err.Error(), "task 2") {
+		t.Errorf("Expected ListTasks to report task 2, got %v", err)
+	}
+	recorder := NewRecorder(s, NewHistory(filepath.Join(t.TempDir(), "history")))
+	if _, err := ListTasks(recorder); err == nil {
+		t.Error("Expected ListTasks to report the broken task through a Recorder")
+	}
+
+	// Nothing is changed while a task cannot be read.
+	if err := ReplaceTasks(s, []*model.Task{readable, {ID: 3, UUID: model.NewUUID(), Title: "New"}}); err == nil {
+		t.Error("Expected ReplaceTasks to fail")
+	}
+	if s.GetTaskByID(3) != nil {
+		t.Error("Expected ReplaceTasks to leave the store alone")
+	}
+	if err := s.RestoreTask(&model.Task{ID: 2, UUID: model.NewUUID()}); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Expected the broken task's ID to count as taken, got %v", err)
+	}
+}
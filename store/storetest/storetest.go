@@ -0,0 +1,385 @@
+// Package storetest provides a conformance suite that every
+// store.TaskRepository implementation must pass.
+package storetest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kevin7254/task/model"
+	"github.com/kevin7254/task/store"
+)
+
+// Factory opens a repository persisting to filename. Opening the same
+// filename again must observe everything written by the previous instance.
+type Factory func(filename string) (store.TaskRepository, error)
+
+// Run exercises the documented TaskRepository contract against the backend
+// created by newStore.
+func Run(t *testing.T, newStore Factory) {
+	t.Run("AssignsUniqueIDs", func(t *testing.T) { testAssignsUniqueIDs(t, newStore) })
+	t.Run("GetMissingReturnsNil", func(t *testing.T) { testGetMissingReturnsNil(t, newStore) })
+	t.Run("UpdateMissingFails", func(t *testing.T) { testUpdateMissingFails(t, newStore) })
+	t.Run("DeleteMissingFails", func(t *testing.T) { testDeleteMissingFails(t, newStore) })
+	t.Run("UpdateAndDelete", func(t *testing.T) { testUpdateAndDelete(t, newStore) })
+	t.Run("RestoreKeepsID", func(t *testing.T) { testRestoreKeepsID(t, newStore) })
+	t.Run("NeverReusesIDs", func(t *testing.T) { testNeverReusesIDs(t, newStore) })
+	t.Run("UUIDsAreImmutable", func(t *testing.T) { testUUIDsAreImmutable(t, newStore) })
+	t.Run("ReturnsCopies", func(t *testing.T) { testReturnsCopies(t, newStore) })
+	t.Run("PersistsAcrossReopen", func(t *testing.T) { testPersistsAcrossReopen(t, newStore) })
+	t.Run("RoundTripsAllFields", func(t *testing.T) { testRoundTripsAllFields(t, newStore) })
+	t.Run("ConcurrentAccess", func(t *testing.T) { testConcurrentAccess(t, newStore) })
+}
+
+// open creates a repository at filename and closes it when the test ends.
+func open(t *testing.T, newStore Factory, filename string) store.TaskRepository {
+	t.Helper()
+	repo, err := newStore(filename)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	if closer, ok := repo.(io.Closer); ok {
+		t.Cleanup(func() { _ = closer.Close() })
+	}
+	return repo
+}
+
+// openTemp creates a repository in a fresh temporary directory.
+func openTemp(t *testing.T, newStore Factory) (store.TaskRepository, string) {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "tasks")
+	return open(t, newStore, filename), filename
+}
+
+// mustAdd adds a task with the given title and returns it.
+func mustAdd(t *testing.T, repo store.TaskRepository, title string) *model.Task {
+	t.Helper()
+	task := &model.Task{Title: title}
+	if err := repo.AddTask(task); err != nil {
+		t.Fatalf("AddTask(%q) failed: %v", title, err)
+	}
+	return task
+}
+
+func testAssignsUniqueIDs(t *testing.T, newStore Factory) {
+	repo, _ := openTemp(t, newStore)
+
+	seen := make(map[int]bool)
+	previous := 0
+	for i := 0; i < 5; i++ {
+		task := mustAdd(t, repo, fmt.Sprintf("Task %d", i))
+		if task.ID <= 0 {
+			t.Errorf("Expected a positive ID, got %d", task.ID)
+		}
+		if seen[task.ID] {
+			t.Errorf("ID %d was assigned twice", task.ID)
+		}
+		if task.ID <= previous {
+			t.Errorf("Expected IDs to increase, got %d after %d", task.ID, previous)
+		}
+		seen[task.ID] = true
+		previous = task.ID
+	}
+
+	if got := len(repo.ListAllTasks()); got != 5 {
+		t.Errorf("Expected 5 tasks, ListAllTasks returned %d", got)
+	}
+}
+
+func testGetMissingReturnsNil(t *testing.T, newStore Factory) {
+	repo, _ := openTemp(t, newStore)
+	if task := repo.GetTaskByID(42); task != nil {
+		t.Errorf("Expected nil for a missing task, got %+v", task)
+	}
+}
+
+func testUpdateMissingFails(t *testing.T, newStore Factory) {
+	repo, _ := openTemp(t, newStore)
+	if err := repo.UpdateTask(&model.Task{ID: 42, Title: "Ghost"}); err == nil {
+		t.Error("Expected an error when updating a missing task")
+	}
+	if len(repo.ListAllTasks()) != 0 {
+		t.Error("Expected a failed update not to create a task")
+	}
+
+	// A failed update must not leave the store unusable.
+	mustAdd(t, repo, "After failed update")
+}
+
+func testDeleteMissingFails(t *testing.T, newStore Factory) {
+	repo, _ := openTemp(t, newStore)
+	if err := repo.DeleteTask(42); err == nil {
+		t.Error("Expected an error when deleting a missing task")
+	}
+}
+
+func testUpdateAndDelete(t *testing.T, newStore Factory) {
+	repo, _ := openTemp(t, newStore)
+	first := mustAdd(t, repo, "First")
+	second := mustAdd(t, repo, "Second")
+
+	first.Title = "First (edited)"
+	if err := repo.UpdateTask(first); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	if got := repo.GetTaskByID(first.ID); got == nil || got.Title != "First (edited)" {
+		t.Errorf("Expected updated title, got %+v", got)
+	}
+
+	if err := repo.DeleteTask(second.ID); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+	if repo.GetTaskByID(second.ID) != nil {
+		t.Error("Expected deleted task to be gone")
+	}
+	if got := len(repo.ListAllTasks()); got != 1 {
+		t.Errorf("Expected 1 task after delete, got %d", got)
+	}
+}
+
+func testRestoreKeepsID(t *testing.T, newStore Factory) {
+	repo, filename := openTemp(t, newStore)
+	removed := fullTask()
+	if err := repo.AddTask(removed); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+	kept := mustAdd(t, repo, "Kept")
+
+	if err := repo.RestoreTask(kept); err == nil {
+		t.Error("Expected an error when restoring a task whose ID is in use")
+	}
+	if err := repo.DeleteTask(removed.ID); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+	if err := repo.RestoreTask(removed); err != nil {
+		t.Fatalf("RestoreTask failed: %v", err)
+	}
+	if closer, ok := repo.(io.Closer); ok {
+		_ = closer.Close()
+	}
+
+	got := open(t, newStore, filename).GetTaskByID(removed.ID)
+	if got == nil {
+		t.Fatalf("Restored task %d not found after reopen", removed.ID)
+	}
+	assertTasksEqual(t, removed, got)
+}
+
+func testNeverReusesIDs(t *testing.T, newStore Factory) {
+	repo, filename := openTemp(t, newStore)
+	mustAdd(t, repo, "First")
+	last := mustAdd(t, repo, "Last")
+	if err := repo.DeleteTask(last.ID); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+	if closer, ok := repo.(io.Closer); ok {
+		_ = closer.Close()
+	}
+
+	next := mustAdd(t, open(t, newStore, filename), "Next")
+	if next.ID <= last.ID {
+		t.Errorf("Expected the ID of removed task %d not to be reused, got %d", last.ID, next.ID)
+	}
+}
+
+func testUUIDsAreImmutable(t *testing.T, newStore Factory) {
+	repo, _ := openTemp(t, newStore)
+	first := mustAdd(t, repo, "First")
+	second := mustAdd(t, repo, "Second")
+	if first.UUID == "" || first.UUID == second.UUID {
+		t.Fatalf("Expected distinct UUIDs to be assigned, got %q and %q", first.UUID, second.UUID)
+	}
+	if got := repo.GetTaskByID(first.ID).UUID; got != first.UUID {
+		t.Errorf("Expected stored UUID %q, got %q", first.UUID, got)
+	}
+
+	kept := &model.Task{Title: "Kept", UUID: "0f8fad5b-d9cb-469f-a165-70867728950e"}
+	if err := repo.AddTask(kept); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+	if kept.UUID != "0f8fad5b-d9cb-469f-a165-70867728950e" {
+		t.Errorf("Expected AddTask to keep an existing UUID, got %q", kept.UUID)
+	}
+
+	first.UUID = second.UUID
+	if err := repo.UpdateTask(first); !errors.Is(err, store.ErrUUIDChanged) {
+		t.Errorf("Expected ErrUUIDChanged, got %v", err)
+	}
+}
+
+func testReturnsCopies(t *testing.T, newStore Factory) {
+	repo, _ := openTemp(t, newStore)
+	added := mustAdd(t, repo, "Original")
+	added.Title = "Changed after add"
+
+	got := repo.GetTaskByID(added.ID)
+	if got.Title != "Original" {
+		t.Errorf("Expected the store to keep its own copy on add, got %q", got.Title)
+	}
+	got.Title = "Changed without update"
+	got.Tags = append(got.Tags, "leaked")
+	if again := repo.GetTaskByID(added.ID); again.Title != "Original" || len(again.Tags) != 0 {
+		t.Errorf("Expected GetTaskByID to return a copy, store now has %+v", again)
+	}
+	repo.ListAllTasks()[0].Title = "Changed in list"
+	if again := repo.GetTaskByID(added.ID); again.Title != "Original" {
+		t.Errorf("Expected ListAllTasks to return copies, store now has %q", again.Title)
+	}
+}
+
+func testPersistsAcrossReopen(t *testing.T, newStore Factory) {
+	repo, filename := openTemp(t, newStore)
+	kept := mustAdd(t, repo, "Kept")
+	removed := mustAdd(t, repo, "Removed")
+	kept.Title = "Kept (edited)"
+	if err := repo.UpdateTask(kept); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	if err := repo.DeleteTask(removed.ID); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+	if closer, ok := repo.(io.Closer); ok {
+		_ = closer.Close()
+	}
+
+	reopened := open(t, newStore, filename)
+	tasks := reopened.ListAllTasks()
+	if len(tasks) != 1 {
+		t.Fatalf("Expected 1 task after reopen, got %d", len(tasks))
+	}
+	if tasks[0].ID != kept.ID || tasks[0].Title != "Kept (edited)" {
+		t.Errorf("Expected task %d with edited title after reopen, got %+v", kept.ID, tasks[0])
+	}
+
+	next := mustAdd(t, reopened, "Next")
+	if next.ID <= kept.ID {
+		t.Errorf("Expected new ID above %d after reopen, got %d", kept.ID, next.ID)
+	}
+}
+
+// fullTask returns a task with every field set to a non-zero value.
+func fullTask() *model.Task {
+	base := time.Date(2025, 3, 14, 9, 26, 53, 589793000, time.UTC)
+	return &model.Task{
+		UUID:        "0f8fad5b-d9cb-469f-a165-70867728950e",
+		Title:       "Round trip",
+		Description: "Every field should survive storage",
+		Project:     "conformance",
+		Priority:    model.High,
+		Status:      model.StatusDone,
+		DueDate:     base.Add(48 * time.Hour),
+		CreatedAt:   base,
+		CompletedAt: base.Add(24 * time.Hour),
+		ArchivedAt:  base.Add(72 * time.Hour),
+		TimeEntries: []model.TimeEntry{
+			{Start: base.Add(-time.Hour), End: base, Legacy: true},
+			{Start: base.Add(time.Hour), End: base.Add(90 * time.Minute)},
+		},
+		Tags:      []string{"review", "urgent"},
+		ParentID:  7,
+		DependsOn: []int{3, 5},
+		Recur: &model.Recurrence{
+			Frequency: model.Weekly,
+			Interval:  2,
+			Weekdays:  []time.Weekday{time.Monday, time.Friday},
+			Until:     base.AddDate(1, 0, 0),
+		},
+		Annotations: []model.Annotation{
+			{Entry: base.Add(time.Hour), Description: "First note"},
+		},
+	}
+}
+
+func testRoundTripsAllFields(t *testing.T, newStore Factory) {
+	want := fullTask()
+
+	// Guard against new Task fields being added without extending fullTask.
+	v := reflect.ValueOf(want).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if name := v.Type().Field(i).Name; name != "ID" && v.Field(i).IsZero() {
+			t.Fatalf("fullTask does not set Task.%s; extend it so the field is round-tripped", name)
+		}
+	}
+
+	repo, filename := openTemp(t, newStore)
+	if err := repo.AddTask(want); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+	if closer, ok := repo.(io.Closer); ok {
+		_ = closer.Close()
+	}
+
+	got := open(t, newStore, filename).GetTaskByID(want.ID)
+	if got == nil {
+		t.Fatalf("Task %d not found after reopen", want.ID)
+	}
+	assertTasksEqual(t, want, got)
+}
+
+// assertTasksEqual compares every field of two tasks, using time.Time.Equal
+// for times so that location and monotonic readings don't matter.
+func assertTasksEqual(t *testing.T, want, got *model.Task) {
+	t.Helper()
+	wv, gv := reflect.ValueOf(want).Elem(), reflect.ValueOf(got).Elem()
+	for i := 0; i < wv.NumField(); i++ {
+		name := wv.Type().Field(i).Name
+		wf, gf := wv.Field(i).Interface(), gv.Field(i).Interface()
+		if wt, ok := wf.(time.Time); ok {
+			if !wt.Equal(gf.(time.Time)) {
+				t.Errorf("Task.%s: want %v, got %v", name, wt, gf)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(wf, gf) {
+			t.Errorf("Task.%s: want %#v, got %#v", name, wf, gf)
+		}
+	}
+}
+
+func testConcurrentAccess(t *testing.T, newStore Factory) {
+	repo, _ := openTemp(t, newStore)
+
+	const workers, perWorker = 4, 10
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*perWorker)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				task := &model.Task{Title: fmt.Sprintf("Worker %d task %d", w, i)}
+				if err := repo.AddTask(task); err != nil {
+					errs <- err
+					continue
+				}
+				_ = repo.ListAllTasks()
+				if repo.GetTaskByID(task.ID) == nil {
+					errs <- fmt.Errorf("task %d not visible after AddTask", task.ID)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	tasks := repo.ListAllTasks()
+	if len(tasks) != workers*perWorker {
+		t.Fatalf("Expected %d tasks, got %d", workers*perWorker, len(tasks))
+	}
+	ids := make(map[int]bool)
+	for _, task := range tasks {
+		if ids[task.ID] {
+			t.Errorf("ID %d was assigned twice", task.ID)
+		}
+		ids[task.ID] = true
+	}
+}
@@ -1,25 +1,57 @@
 package store
 
-import "github.com/kevin7254/task/model"
+import (
+	"errors"
+
+	"github.com/kevin7254/task/model"
+)
+
+// ErrUUIDChanged is returned when an update would change a task's UUID.
+var ErrUUIDChanged = errors.New("the UUID of a task cannot be changed")
 
 // TaskRepository defines the operations that can be performed on a task store.
 type TaskRepository interface {
-	// AddTask adds a task to the store and assigns it a unique ID.
+	// AddTask adds a task to the store and assigns it a unique ID, and a UUID
+	// if it has none. IDs are never reused, not even those of removed tasks.
 	// Returns an error if the operation fails.
 	AddTask(t *model.Task) error
 
+	// RestoreTask adds a previously removed task back under its original ID.
+	// Returns an error if a task with that ID already exists.
+	RestoreTask(t *model.Task) error
+
 	// ListAllTasks returns all tasks in the store.
 	ListAllTasks() []*model.Task
 
 	// GetTaskByID retrieves a task by its ID.
-	// Returns nil if no task with the given ID exists.
+	// Returns nil if no task with the given ID exists. The returned task is a
+	// copy; changes to it are only stored by UpdateTask.
 	GetTaskByID(id int) *model.Task
 
 	// UpdateTask updates an existing task.
-	// Returns an error if the task doesn't exist or the operation fails.
+	// Returns an error if the task doesn't exist, its UUID would change
+	// (ErrUUIDChanged) or the operation fails.
 	UpdateTask(t *model.Task) error
 
 	// DeleteTask removes a task from the store.
 	// Returns an error if the task doesn't exist or the operation fails.
 	DeleteTask(id int) error
 }
+
+// TaskLister is implemented by stores that can fail to read their tasks, such
+// as SQLiteStore, whose ListAllTasks leaves out rows it cannot decode.
+type TaskLister interface {
+	// ListTasks returns all tasks in the store, or an error if any of them
+	// cannot be read.
+	ListTasks() ([]*model.Task, error)
+}
+
+// ListTasks returns all tasks in repo. Unlike ListAllTasks it fails rather
+// than leave out tasks that cannot be read, which matters before tasks are
+// deleted or saved elsewhere.
+func ListTasks(repo TaskRepository) ([]*model.Task, error) {
+	if lister, ok := repo.(TaskLister); ok {
+		return lister.ListTasks()
+	}
+	return repo.ListAllTasks(), nil
+}